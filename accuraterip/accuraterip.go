@@ -0,0 +1,303 @@
+// Package accuraterip verifies CD rips against AccurateRip database.
+// Database responses (dBAR-*.bin files) are read from the local storage,
+// so verification works offline.
+package accuraterip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// Number of bytes in one CD frame (sector) of 16-bit stereo PCM.
+const frameSize = 2352
+
+// Number of stereo samples in one CD frame.
+const frameSamples = frameSize / 4
+
+// Number of samples skipped at the disc start and end: five frames.
+const skipSamples = 5 * frameSamples
+
+// Gap between the audio session and the data track of the enhanced CD
+// in frames: lead-out and lead-in of the sessions and the data track pregap.
+const sessionGapFrames = 11400
+
+// Options configures verification.
+type Options struct {
+	// Read offset in samples: disc sample i is the sample i+Offset
+	// of the audio data. Samples out of the audio data are zeros.
+	Offset int
+}
+
+// TrackResult is a verification result of one track.
+type TrackResult struct {
+	// Track number.
+	Number int
+	// AccurateRip CRCs of the track audio.
+	CRCv1 uint32
+	CRCv2 uint32
+	// Number of database submissions with the same CRC.
+	// Zero if the track doesn't match the database.
+	Confidence int
+	// CRC version matched: 1 or 2. Zero if the track doesn't match the database.
+	Version int
+}
+
+// Result is a verification result of the disc.
+type Result struct {
+	// Disc identifiers.
+	ID cue.AccurateRipID
+	// Audio tracks results.
+	Tracks []TrackResult
+}
+
+// Accurate returns true if all the tracks match the database.
+func (r *Result) Accurate() bool {
+	for _, track := range r.Tracks {
+		if track.Confidence == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Verify checks the disc audio against the AccurateRip database response.
+// audio is 16-bit little endian stereo PCM of the whole disc described by
+// the single-file sheet. Disc length is taken from the audio size, so audio
+// should implement Size() int64 method (like io.SectionReader does)
+// or be an *os.File. Data track of the enhanced CD should start the session
+// gap (11400 frames) after the audio, e.g. the gap can be set with PREGAP.
+func Verify(sheet *cue.CueSheet, audio io.ReaderAt, db io.Reader) (*Result, error) {
+	return VerifyWithOptions(sheet, audio, db, Options{})
+}
+
+// VerifyWithOptions is like Verify but uses the given options.
+func VerifyWithOptions(sheet *cue.CueSheet, audio io.ReaderAt, db io.Reader, opts Options) (*Result, error) {
+	size, err := audioSize(audio)
+	if err != nil {
+		return nil, err
+	}
+	if size%frameSize != 0 {
+		return nil, fmt.Errorf("Audio size is not a multiple of CD frame size.")
+	}
+
+	toc, err := sheet.TOC(cue.TimeFromFrames(int(size / frameSize)))
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{ID: toc.AccurateRipID()}
+
+	entries, err := ReadDatabase(db)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &sampleReader{audio: audio, samples: size / 4, offset: int64(opts.Offset)}
+	// Audio tracks end where the next track starts. The last track of the
+	// enhanced CD audio session ends the session gap before the data track.
+	var tracks []cue.TOCTrack
+	var ends []int
+	for i, track := range toc.Tracks {
+		if track.Data {
+			continue
+		}
+		end := toc.Leadout
+		if i+1 < len(toc.Tracks) {
+			end = toc.Tracks[i+1].Offset
+			if toc.Tracks[i+1].Data {
+				end -= sessionGapFrames
+			}
+		}
+		if end <= track.Offset {
+			return nil, fmt.Errorf("Track %d is followed by data track without session gap.",
+				track.Number)
+		}
+		tracks = append(tracks, track)
+		ends = append(ends, end)
+	}
+	for i, track := range tracks {
+		start := int64(track.Offset) * frameSamples
+		end := int64(ends[i]) * frameSamples
+
+		// The first and the last five frames of the disc are not checked.
+		first, last := int64(1), end-start
+		if i == 0 {
+			first = skipSamples
+		}
+		if i == len(tracks)-1 {
+			last -= skipSamples
+		}
+
+		tr := TrackResult{Number: track.Number}
+		tr.CRCv1, tr.CRCv2, err = r.crc(start, end, first, last)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.ID != result.ID || i >= len(entry.Tracks) {
+				continue
+			}
+			switch entry.Tracks[i].CRC {
+			case tr.CRCv1:
+				tr.Confidence += entry.Tracks[i].Confidence
+				tr.Version = 1
+			case tr.CRCv2:
+				tr.Confidence += entry.Tracks[i].Confidence
+				tr.Version = 2
+			}
+		}
+		result.Tracks = append(result.Tracks, tr)
+	}
+
+	return result, nil
+}
+
+// Entry is one record of the AccurateRip database response.
+// Response can contain several entries for different disc pressings.
+type Entry struct {
+	// Disc identifiers.
+	ID cue.AccurateRipID
+	// Tracks CRCs.
+	Tracks []EntryTrack
+}
+
+// EntryTrack is a track CRC stored in the database.
+type EntryTrack struct {
+	// Number of submissions with the CRC.
+	Confidence int
+	// Track CRC, either v1 or v2.
+	CRC uint32
+	// CRC of the track frame 450, used for read offset detection.
+	CRC450 uint32
+}
+
+// ReadDatabase reads AccurateRip database response (dBAR-*.bin file).
+func ReadDatabase(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	for {
+		var header struct {
+			Tracks   uint8
+			ID1, ID2 uint32
+			CDDB     uint32
+		}
+		err := binary.Read(r, binary.LittleEndian, &header)
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read database entry. %s", err.Error())
+		}
+
+		entry := Entry{ID: cue.AccurateRipID{
+			Tracks: int(header.Tracks),
+			ID1:    header.ID1,
+			ID2:    header.ID2,
+			CDDB:   header.CDDB,
+		}}
+		for i := 0; i < int(header.Tracks); i++ {
+			var track struct {
+				Confidence uint8
+				CRC        uint32
+				CRC450     uint32
+			}
+			if err := binary.Read(r, binary.LittleEndian, &track); err != nil {
+				return nil, fmt.Errorf("Failed to read database entry. %s", err.Error())
+			}
+			entry.Tracks = append(entry.Tracks, EntryTrack{
+				Confidence: int(track.Confidence),
+				CRC:        track.CRC,
+				CRC450:     track.CRC450,
+			})
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// audioSize returns size of the audio data in bytes.
+func audioSize(audio io.ReaderAt) (int64, error) {
+	switch a := audio.(type) {
+	case interface{ Size() int64 }:
+		return a.Size(), nil
+	case *os.File:
+		fi, err := a.Stat()
+		if err != nil {
+			return 0, err
+		}
+		return fi.Size(), nil
+	}
+
+	return 0, fmt.Errorf("Audio size is unknown.")
+}
+
+// sampleReader reads stereo samples of the disc.
+type sampleReader struct {
+	audio io.ReaderAt
+	// Number of samples in the audio data.
+	samples int64
+	// Read offset in samples.
+	offset int64
+}
+
+// crc returns AccurateRip v1 and v2 CRCs of samples [start, end). Only samples
+// with 1-based position in the track between first and last are counted.
+func (r *sampleReader) crc(start int64, end int64, first int64, last int64) (uint32, uint32, error) {
+	var v1, v2 uint32
+	buf := make([]byte, 64*frameSize)
+
+	for pos := start; pos < end; {
+		n := int64(len(buf) / 4)
+		if end-pos < n {
+			n = end - pos
+		}
+		if err := r.read(buf[:n*4], pos); err != nil {
+			return 0, 0, err
+		}
+
+		for i := int64(0); i < n; i++ {
+			mul := pos - start + i + 1
+			if mul < first || mul > last {
+				continue
+			}
+			sample := binary.LittleEndian.Uint32(buf[i*4:])
+			v1 += sample * uint32(mul)
+			p := uint64(sample) * uint64(mul)
+			v2 += uint32(p) + uint32(p>>32)
+		}
+		pos += n
+	}
+
+	return v1, v2, nil
+}
+
+// read fills buf with samples starting from the disc sample pos.
+func (r *sampleReader) read(buf []byte, pos int64) error {
+	for i := range buf {
+		buf[i] = 0
+	}
+
+	from := pos + r.offset
+	to := from + int64(len(buf)/4)
+	skip := int64(0)
+	if from < 0 {
+		skip = -from
+		from = 0
+	}
+	if to > r.samples {
+		to = r.samples
+	}
+	if from >= to {
+		return nil
+	}
+
+	_, err := r.audio.ReadAt(buf[skip*4:skip*4+(to-from)*4], from*4)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("Failed to read audio. %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package accuraterip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+const testSheet = "FILE \"disc.wav\" WAVE\n" +
+	"  TRACK 01 AUDIO\n" +
+	"    INDEX 01 00:00:00\n" +
+	"  TRACK 02 AUDIO\n" +
+	"    INDEX 01 00:00:20\n" +
+	"  TRACK 03 AUDIO\n" +
+	"    INDEX 01 00:00:40\n"
+
+// Number of frames in the test disc.
+const testFrames = 60
+
+// naiveCRC returns v1 and v2 CRCs of the track samples.
+func naiveCRC(samples []uint32, first int, last int) (uint32, uint32) {
+	var v1, v2 uint64
+	for i, s := range samples {
+		mul := uint64(i + 1)
+		if int(mul) >= first && int(mul) <= last {
+			v1 += uint64(s) * mul
+			p := uint64(s) * mul
+			v2 += p&0xFFFFFFFF + p>>32
+		}
+	}
+
+	return uint32(v1), uint32(v2)
+}
+
+// database returns dBAR file with the given CRCs.
+func database(id cue.AccurateRipID, crcs []uint32) []byte {
+	buf := new(bytes.Buffer)
+	write := func(values ...interface{}) {
+		for _, v := range values {
+			binary.Write(buf, binary.LittleEndian, v)
+		}
+	}
+	write(uint8(len(crcs)), id.ID1, id.ID2, id.CDDB)
+	for _, crc := range crcs {
+		write(uint8(7), crc, uint32(0))
+	}
+
+	return buf.Bytes()
+}
+
+func TestVerify(t *testing.T) {
+	sheet, err := cue.Parse(strings.NewReader(testSheet))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	samples := make([]uint32, testFrames*frameSamples)
+	audio := make([]byte, len(samples)*4)
+	for i := range samples {
+		samples[i] = rnd.Uint32()
+		binary.LittleEndian.PutUint32(audio[i*4:], samples[i])
+	}
+
+	n := 20 * frameSamples
+	var v1, v2 []uint32
+	for i := 0; i < 3; i++ {
+		first, last := 1, n
+		if i == 0 {
+			first = skipSamples
+		}
+		if i == 2 {
+			last -= skipSamples
+		}
+		c1, c2 := naiveCRC(samples[i*n:(i+1)*n], first, last)
+		v1 = append(v1, c1)
+		v2 = append(v2, c2)
+	}
+
+	toc, err := sheet.TOC(cue.TimeFromFrames(testFrames))
+	if err != nil {
+		t.Fatalf("Failed to build TOC. %s", err.Error())
+	}
+	id := toc.AccurateRipID()
+	other := id
+	other.ID1++
+	// Entry of other disc followed by v1 and v2 entries of the disc.
+	db := append(database(other, v1), database(id, v1)...)
+	db = append(db, database(id, []uint32{0, v2[1], v2[2]})...)
+
+	result, err := Verify(sheet, bytes.NewReader(audio), bytes.NewReader(db))
+	if err != nil {
+		t.Fatalf("Failed to verify. %s", err.Error())
+	}
+	if !result.Accurate() {
+		t.Fatalf("Rip is not accurate: %+v", result)
+	}
+	for i, track := range result.Tracks {
+		if track.CRCv1 != v1[i] || track.CRCv2 != v2[i] {
+			t.Fatalf("Track %d CRCs are %08x %08x but %08x %08x expected",
+				track.Number, track.CRCv1, track.CRCv2, v1[i], v2[i])
+		}
+		if track.Confidence != 7 && track.Confidence != 14 {
+			t.Fatalf("Unexpected track %d confidence %d", track.Number, track.Confidence)
+		}
+	}
+
+	// Audio read with 5 samples offset.
+	shifted := append(make([]byte, 5*4), audio[:len(audio)-5*4]...)
+	result, err = Verify(sheet, bytes.NewReader(shifted), bytes.NewReader(db))
+	if err != nil {
+		t.Fatalf("Failed to verify. %s", err.Error())
+	}
+	if result.Accurate() {
+		t.Fatalf("Shifted audio is accurate")
+	}
+	result, err = VerifyWithOptions(sheet, bytes.NewReader(shifted), bytes.NewReader(db), Options{Offset: 5})
+	if err != nil {
+		t.Fatalf("Failed to verify. %s", err.Error())
+	}
+	if !result.Accurate() {
+		t.Fatalf("Shifted audio is not accurate with offset: %+v", result)
+	}
+}
+
+func TestVerifyDataTrack(t *testing.T) {
+	// Enhanced CD: two audio tracks of 20 frames, the session gap
+	// and 20 frames of the data track.
+	sheet, err := cue.Parse(strings.NewReader("FILE \"disc.bin\" BINARY\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    INDEX 01 00:00:20\n" +
+		"  TRACK 03 MODE1/2352\n" +
+		"    PREGAP 02:32:00\n" +
+		"    INDEX 01 00:00:40\n"))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	// Audio samples are 1 (left channel 1, right channel 0),
+	// data track bytes are 0xFF.
+	audio := bytes.Repeat([]byte{0xFF}, testFrames*frameSize)
+	for i := 0; i < 40*frameSamples; i++ {
+		binary.LittleEndian.PutUint32(audio[i*4:], 1)
+	}
+
+	// CRC of the samples equal to 1 is the sum of the sample positions:
+	// 2940..11760 for the first track and 1..8820 for the last one.
+	crcs := []uint32{64834350, 38900610}
+	// Tracks start at 0, 20 and 11440, lead-out is 11460. ID1 is
+	// 0 + 20 + 11460, ID2 is 1*1 + 20*2 + 11460*3.
+	id := cue.AccurateRipID{Tracks: 2, ID1: 11480, ID2: 34421, CDDB: 0x0E009803}
+	db := database(id, crcs)
+
+	result, err := Verify(sheet, bytes.NewReader(audio), bytes.NewReader(db))
+	if err != nil {
+		t.Fatalf("Failed to verify. %s", err.Error())
+	}
+	if result.ID != id {
+		t.Fatalf("Disc ID %+v but %+v expected", result.ID, id)
+	}
+	if len(result.Tracks) != 2 || !result.Accurate() {
+		t.Fatalf("Rip is not accurate: %+v", result)
+	}
+	for i, track := range result.Tracks {
+		if track.CRCv1 != crcs[i] || track.CRCv2 != crcs[i] || track.Version != 1 {
+			t.Fatalf("Track %d CRCs are %08x %08x but %08x expected",
+				track.Number, track.CRCv1, track.CRCv2, crcs[i])
+		}
+	}
+
+	// Data track without session gap.
+	sheet.Files[0].Tracks[2].Pregap = cue.Time{}
+	if _, err := Verify(sheet, bytes.NewReader(audio), bytes.NewReader(db)); err == nil {
+		t.Fatalf("Data track without session gap is accepted")
+	}
+}
+
+func TestReadDatabase(t *testing.T) {
+	id := cue.AccurateRipID{Tracks: 2, ID1: 1, ID2: 2, CDDB: 3}
+	data := database(id, []uint32{10, 20})
+
+	entries, err := ReadDatabase(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to read. %s", err.Error())
+	}
+	if len(entries) != 1 || entries[0].ID != id || entries[0].Tracks[1].CRC != 20 {
+		t.Fatalf("Unexpected entries %+v", entries)
+	}
+
+	if _, err := ReadDatabase(bytes.NewReader(data[:len(data)-1])); err == nil {
+		t.Fatalf("Truncated file is read")
+	}
+}
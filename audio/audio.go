@@ -0,0 +1,234 @@
+// Package audio splits and joins PCM audio files described by cue sheets.
+// WAVE files and raw CD audio (BINARY and MOTOROLA) are supported,
+// output files are WAVE.
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// CreateFunc creates output file with the given name.
+type CreateFunc func(name string) (io.WriteCloser, error)
+
+// format is PCM audio format.
+type format struct {
+	sampleRate int
+	channels   int
+	bitDepth   int
+	float      bool
+}
+
+// blockAlign returns size of one sample of all channels in bytes.
+func (f format) blockAlign() int {
+	return (f.bitDepth + 7) / 8 * f.channels
+}
+
+// source is an opened sheet file.
+type source struct {
+	file fs.File
+	r    io.ReadSeeker
+	info *cue.AudioInfo
+}
+
+// sources are all the sheet files.
+type sources []*source
+
+// openSources opens all the files of the sheet located in the dir of fsys.
+// All the files should have the same format.
+func openSources(fsys fs.FS, dir string, sheet *cue.CueSheet) (sources, format, error) {
+	var f format
+
+	paths, err := cue.Resolve(fsys, dir, sheet)
+	if err != nil {
+		return nil, f, err
+	}
+
+	var srcs sources
+	for i, p := range paths {
+		src, err := openSource(fsys, p, sheet.Files[i].Type)
+		if err != nil {
+			srcs.Close()
+			return nil, f, fmt.Errorf("Failed to open %s. %s", p, err.Error())
+		}
+		srcs = append(srcs, src)
+
+		info := src.info
+		ff := format{info.SampleRate, info.Channels, info.BitDepth, info.Float}
+		if i == 0 {
+			f = ff
+		} else if ff != f {
+			srcs.Close()
+			return nil, f, fmt.Errorf("Format of %s differs from the format of %s.", p, paths[0])
+		}
+	}
+
+	return srcs, f, nil
+}
+
+// openSource opens and probes the file.
+func openSource(fsys fs.FS, name string, fileType cue.FileType) (*source, error) {
+	file, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r, ok := file.(io.ReadSeeker)
+	if !ok {
+		file.Close()
+		return nil, fmt.Errorf("File doesn't support seeking.")
+	}
+	info, err := cue.Probe(r, fileType)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	switch {
+	case info.Type == cue.FileTypeWave || info.Type == cue.FileTypeBinary:
+	case info.Type == cue.FileTypeMotorola && info.BitDepth == 16:
+	default:
+		file.Close()
+		return nil, fmt.Errorf("File is neither PCM WAVE nor raw CD audio.")
+	}
+
+	return &source{file, r, info}, nil
+}
+
+// Close closes all the files.
+func (srcs sources) Close() {
+	for _, src := range srcs {
+		src.file.Close()
+	}
+}
+
+// lengths returns lengths of all the files.
+func (srcs sources) lengths() []cue.Time {
+	lengths := make([]cue.Time, len(srcs))
+	for i, src := range srcs {
+		lengths[i] = src.info.Length
+	}
+
+	return lengths
+}
+
+// sampleRange returns samples [from, to) range of the file segment.
+// Segment ending with the last frame of the file includes samples
+// of the last incomplete frame.
+func (srcs sources) sampleRange(seg cue.Segment) (int64, int64) {
+	info := srcs[seg.File].info
+	end := seg.Start.Add(seg.Length)
+
+	from := seg.Start.Samples(info.SampleRate)
+	to := end.Samples(info.SampleRate)
+	if end == info.Length {
+		to = info.Samples
+	}
+
+	return from, to
+}
+
+// write writes segments audio as WAVE file.
+func (srcs sources) write(w io.Writer, f format, segments []cue.Segment) error {
+	var samples int64
+	for _, seg := range segments {
+		from, to := srcs.sampleRange(seg)
+		samples += to - from
+	}
+	if err := writeHeader(w, f, samples); err != nil {
+		return err
+	}
+
+	align := int64(f.blockAlign())
+	for _, seg := range segments {
+		src := srcs[seg.File]
+		from, to := srcs.sampleRange(seg)
+
+		if _, err := src.r.Seek(src.info.DataOffset+from*align, io.SeekStart); err != nil {
+			return err
+		}
+		dst := w
+		if src.info.Type == cue.FileTypeMotorola {
+			dst = &swapWriter{w: w}
+		}
+		if _, err := io.CopyN(dst, src.r, (to-from)*align); err != nil {
+			return fmt.Errorf("Failed to copy audio. %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// WAVE format tags.
+const (
+	wavFormatPCM   = 0x0001
+	wavFormatFloat = 0x0003
+)
+
+// writeHeader writes header of the WAVE file with the given number of samples.
+func writeHeader(w io.Writer, f format, samples int64) error {
+	align := f.blockAlign()
+	size := samples * int64(align)
+	if size+36 > math.MaxUint32 {
+		return fmt.Errorf("Audio is too long for WAVE file.")
+	}
+
+	tag := uint16(wavFormatPCM)
+	if f.float {
+		tag = wavFormatFloat
+	}
+	header := struct {
+		Riff          [4]byte
+		RiffSize      uint32
+		Wave          [4]byte
+		Fmt           [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		[4]byte{'R', 'I', 'F', 'F'}, uint32(size + 36), [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, 16, tag, uint16(f.channels), uint32(f.sampleRate),
+		uint32(f.sampleRate * align), uint16(align), uint16(f.bitDepth),
+		[4]byte{'d', 'a', 't', 'a'}, uint32(size),
+	}
+
+	return binary.Write(w, binary.LittleEndian, &header)
+}
+
+// swapWriter converts 16-bit big endian samples to little endian ones.
+type swapWriter struct {
+	w io.Writer
+	// Odd byte left from the previous write.
+	odd  []byte
+	swap []byte
+}
+
+// Write implements io.Writer interface.
+func (sw *swapWriter) Write(p []byte) (int, error) {
+	// Odd byte and p are joined in swap buffer, which doesn't share
+	// memory with the odd one.
+	sw.swap = append(append(sw.swap[:0], sw.odd...), p...)
+	n := len(sw.swap) &^ 1
+	sw.odd = append(sw.odd[:0], sw.swap[n:]...)
+
+	data := sw.swap[:n]
+	for i := 0; i < n; i += 2 {
+		data[i], data[i+1] = data[i+1], data[i]
+	}
+	if _, err := sw.w.Write(data); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,31 @@
+package audio
+
+import (
+	"io/fs"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// Join concatenates all the sheet files located in the dir of fsys into one
+// WAVE file created with create function under the given name. Returns
+// single-file sheet with indexes (including INDEX 00 pregaps) rebased
+// onto the created file.
+func Join(sheet *cue.CueSheet, fsys fs.FS, dir string, create CreateFunc, name string) (*cue.CueSheet, error) {
+	srcs, f, err := openSources(fsys, dir, sheet)
+	if err != nil {
+		return nil, err
+	}
+	defer srcs.Close()
+
+	toc, err := sheet.TOC(srcs.lengths()...)
+	if err != nil {
+		return nil, err
+	}
+
+	image := cue.FileRange{Name: name, Type: cue.FileTypeWave, Start: 0, End: toc.Leadout}
+	if err := writeFile(create, name, srcs, f, toc.Segments(image.Start, image.End)); err != nil {
+		return nil, err
+	}
+
+	return sheet.Rebase(toc, []cue.FileRange{image}), nil
+}
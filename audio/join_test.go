@@ -0,0 +1,82 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+func TestJoin(t *testing.T) {
+	sheet := `REM GENRE Rock
+TITLE "Album"
+FILE "01.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Two"
+    INDEX 00 00:00:40
+FILE "02.wav" WAVE
+    INDEX 01 00:00:00
+FILE "03.wav" WAVE
+  TRACK 03 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:00:05
+`
+	etalon := `REM GENRE Rock
+TITLE "Album"
+FILE "image.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Two"
+    INDEX 00 00:00:40
+    INDEX 01 00:00:50
+  TRACK 03 AUDIO
+    INDEX 00 00:01:05
+    INDEX 01 00:01:10
+`
+	fsys := fstest.MapFS{
+		"01.wav": {Data: waveImage(50)},
+		"02.wav": {Data: waveImage(30)},
+		"03.wav": {Data: waveImage(20)},
+	}
+
+	s, err := cue.Parse(strings.NewReader(sheet))
+	if err != nil {
+		t.Fatalf("Failed to parse sheet. %s", err.Error())
+	}
+	out := outputs{}
+	result, err := Join(s, fsys, ".", out.create, "image.wav")
+	if err != nil {
+		t.Fatalf("Failed to join. %s", err.Error())
+	}
+
+	data := out["image.wav"].Bytes()
+	if _, _, n := frames(t, data); n != 100 {
+		t.Fatalf("%d frames recieved but 100 expected.", n)
+	}
+	// Frame numbers of the files restart at their boundaries.
+	for i := 0; i < 100; i++ {
+		expected := i
+		if i >= 80 {
+			expected = i - 80
+		} else if i >= 50 {
+			expected = i - 50
+		}
+		if f := int(binary.LittleEndian.Uint32(data[44+i*2352:])); f != expected {
+			t.Fatalf("Frame %d stores %d but %d expected.", i, f, expected)
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	if err := cue.Write(buf, result); err != nil {
+		t.Fatalf("Failed to write sheet. %s", err.Error())
+	}
+	if buf.String() != etalon {
+		t.Fatalf("Sheet\n%s\nrecieved but\n%s\nexpected.", buf.String(), etalon)
+	}
+}
@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// GapMode defines where track pregaps (audio between INDEX 00
+// and INDEX 01) are placed while splitting.
+type GapMode int
+
+const (
+	// Pregap is appended to the previous track file.
+	GapsAppended GapMode = iota
+	// Pregap is prepended to the track file.
+	GapsPrepended
+	// Pregap audio is discarded.
+	GapsDiscarded
+)
+
+// SplitOptions configures splitting.
+type SplitOptions struct {
+	// Pregaps placement.
+	Gaps GapMode
+	// Hidden track one audio (track 1 pregap) is extracted into a separate
+	// file named as track 0. Otherwise it goes to track 1 file with
+	// GapsPrepended mode and is discarded with other modes.
+	HTOA bool
+	// Name returns file name for the track. Default names are "01.wav",
+	// "02.wav", etc.
+	Name func(number int) string
+}
+
+// Split writes audio of every sheet track into a separate WAVE file created
+// with create function. Sheet files are located in the dir of fsys.
+// Returns sheet describing the created files.
+func Split(sheet *cue.CueSheet, fsys fs.FS, dir string, create CreateFunc, opts SplitOptions) (*cue.CueSheet, error) {
+	srcs, f, err := openSources(fsys, dir, sheet)
+	if err != nil {
+		return nil, err
+	}
+	defer srcs.Close()
+
+	toc, err := sheet.TOC(srcs.lengths()...)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := splitRanges(toc, opts)
+	for _, r := range ranges {
+		if err := writeFile(create, r.Name, srcs, f, toc.Segments(r.Start, r.End)); err != nil {
+			return nil, err
+		}
+	}
+
+	return sheet.Rebase(toc, ranges), nil
+}
+
+// splitRanges returns disc ranges of the track files.
+func splitRanges(toc *cue.TOC, opts SplitOptions) []cue.FileRange {
+	name := opts.Name
+	if name == nil {
+		name = func(number int) string {
+			return fmt.Sprintf("%02d.wav", number)
+		}
+	}
+
+	var ranges []cue.FileRange
+	tracks := toc.Tracks
+	for i, track := range tracks {
+		start := track.Offset
+		if opts.Gaps == GapsPrepended {
+			start = track.Indexes[0].Offset
+			if i == 0 {
+				start = 0
+			}
+		}
+		end := toc.Leadout
+		if i+1 < len(tracks) {
+			end = tracks[i+1].Offset
+			if opts.Gaps != GapsAppended {
+				end = tracks[i+1].Indexes[0].Offset
+			}
+		}
+
+		ranges = append(ranges, cue.FileRange{
+			Name:  name(track.Number),
+			Type:  cue.FileTypeWave,
+			Start: start,
+			End:   end,
+		})
+	}
+
+	first := tracks[0].Offset
+	if opts.HTOA && len(toc.Segments(0, first)) > 0 {
+		ranges[0].Start = first
+		htoa := cue.FileRange{Name: name(0), Type: cue.FileTypeWave, Start: 0, End: first}
+		ranges = append([]cue.FileRange{htoa}, ranges...)
+	}
+
+	return ranges
+}
+
+// writeFile creates file with the segments audio.
+func writeFile(create CreateFunc, name string, srcs sources, f format, segments []cue.Segment) error {
+	w, err := create(name)
+	if err != nil {
+		return err
+	}
+	if err := srcs.write(w, f, segments); err != nil {
+		w.Close()
+		return fmt.Errorf("Failed to write %s. %s", name, err.Error())
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,290 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// Format of the test audio: CD audio, 588 samples per frame.
+var cdFormat = format{44100, 2, 16, false}
+
+// image returns PCM audio of the given number of frames. Every sample
+// of the frame stores the frame number in the first channel, so positions can be checked.
+func image(frames int, order binary.ByteOrder) []byte {
+	buf := new(bytes.Buffer)
+	for f := 0; f < frames; f++ {
+		for s := 0; s < 588; s++ {
+			binary.Write(buf, order, [2]uint16{uint16(f), 0})
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// waveImage returns WAVE file with image audio.
+func waveImage(frames int) []byte {
+	buf := new(bytes.Buffer)
+	writeHeader(buf, cdFormat, int64(frames*588))
+	buf.Write(image(frames, binary.LittleEndian))
+
+	return buf.Bytes()
+}
+
+// buffer is in-memory output file.
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) Close() error {
+	return nil
+}
+
+// outputs collects created files.
+type outputs map[string]*buffer
+
+func (o outputs) create(name string) (io.WriteCloser, error) {
+	b := new(buffer)
+	o[name] = b
+
+	return b, nil
+}
+
+// frames returns first and last frame numbers stored in the WAVE file
+// and number of frames.
+func frames(t *testing.T, data []byte) (int, int, int) {
+	if len(data) < 44 || string(data[:4]) != "RIFF" {
+		t.Fatalf("Not a WAVE file.")
+	}
+	size := int(binary.LittleEndian.Uint32(data[40:]))
+	if size != len(data)-44 {
+		t.Fatalf("Data size %d recieved but %d expected.", size, len(data)-44)
+	}
+	data = data[44:]
+	if len(data) == 0 {
+		return -1, -1, 0
+	}
+
+	return int(binary.LittleEndian.Uint32(data)),
+		int(binary.LittleEndian.Uint32(data[len(data)-4:])),
+		len(data) / 2352
+}
+
+const splitSheet = `FILE "image.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:00:10
+  TRACK 02 AUDIO
+    INDEX 00 00:00:40
+    INDEX 01 00:00:50
+  TRACK 03 AUDIO
+    INDEX 01 00:01:00
+`
+
+func TestSplit(t *testing.T) {
+	fsys := fstest.MapFS{"rip/image.wav": {Data: waveImage(100)}}
+
+	tests := []struct {
+		opts SplitOptions
+		// Expected first and last frames of every file.
+		ranges map[string][2]int
+		sheet  string
+	}{
+		{SplitOptions{Gaps: GapsAppended},
+			map[string][2]int{"01.wav": {10, 49}, "02.wav": {50, 74}, "03.wav": {75, 99}},
+			`FILE "01.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    INDEX 00 00:00:30
+FILE "02.wav" WAVE
+    INDEX 01 00:00:00
+FILE "03.wav" WAVE
+  TRACK 03 AUDIO
+    INDEX 01 00:00:00
+`},
+		{SplitOptions{Gaps: GapsPrepended},
+			map[string][2]int{"01.wav": {0, 39}, "02.wav": {40, 74}, "03.wav": {75, 99}},
+			`FILE "01.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:00:10
+FILE "02.wav" WAVE
+  TRACK 02 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:00:10
+FILE "03.wav" WAVE
+  TRACK 03 AUDIO
+    INDEX 01 00:00:00
+`},
+		{SplitOptions{Gaps: GapsDiscarded},
+			map[string][2]int{"01.wav": {10, 39}, "02.wav": {50, 74}, "03.wav": {75, 99}},
+			`FILE "01.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+FILE "02.wav" WAVE
+  TRACK 02 AUDIO
+    INDEX 01 00:00:00
+FILE "03.wav" WAVE
+  TRACK 03 AUDIO
+    INDEX 01 00:00:00
+`},
+		{SplitOptions{Gaps: GapsPrepended, HTOA: true, Name: func(n int) string {
+			return fmt.Sprintf("track%d.wav", n)
+		}},
+			map[string][2]int{"track0.wav": {0, 9}, "track1.wav": {10, 39},
+				"track2.wav": {40, 74}, "track3.wav": {75, 99}},
+			`FILE "track0.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 00 00:00:00
+FILE "track1.wav" WAVE
+    INDEX 01 00:00:00
+FILE "track2.wav" WAVE
+  TRACK 02 AUDIO
+    INDEX 00 00:00:00
+    INDEX 01 00:00:10
+FILE "track3.wav" WAVE
+  TRACK 03 AUDIO
+    INDEX 01 00:00:00
+`},
+	}
+
+	for _, test := range tests {
+		sheet, err := cue.Parse(strings.NewReader(splitSheet))
+		if err != nil {
+			t.Fatalf("Failed to parse sheet. %s", err.Error())
+		}
+		out := outputs{}
+		result, err := Split(sheet, fsys, "rip", out.create, test.opts)
+		if err != nil {
+			t.Fatalf("Failed to split. %s", err.Error())
+		}
+
+		if len(out) != len(test.ranges) {
+			t.Fatalf("%d files created but %d expected.", len(out), len(test.ranges))
+		}
+		for name, r := range test.ranges {
+			b, ok := out[name]
+			if !ok {
+				t.Fatalf("File %s was not created.", name)
+			}
+			first, last, n := frames(t, b.Bytes())
+			if first != r[0] || last != r[1] || n != r[1]-r[0]+1 {
+				t.Fatalf("%s: frames %d-%d (%d) recieved but %d-%d expected.",
+					name, first, last, n, r[0], r[1])
+			}
+		}
+
+		buf := new(bytes.Buffer)
+		if err := cue.Write(buf, result); err != nil {
+			t.Fatalf("Failed to write sheet. %s", err.Error())
+		}
+		if buf.String() != test.sheet {
+			t.Fatalf("Sheet\n%s\nrecieved but\n%s\nexpected.", buf.String(), test.sheet)
+		}
+	}
+}
+
+func TestSplitRaw(t *testing.T) {
+	// Raw big endian audio with PREGAP silence which is not stored in files.
+	sheet := `FILE "a.bin" MOTOROLA
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    PREGAP 00:00:20
+    INDEX 01 00:00:30
+`
+	fsys := fstest.MapFS{"a.bin": {Data: image(60, binary.BigEndian)}}
+
+	s, err := cue.Parse(strings.NewReader(sheet))
+	if err != nil {
+		t.Fatalf("Failed to parse sheet. %s", err.Error())
+	}
+	out := outputs{}
+	if _, err := Split(s, fsys, ".", out.create, SplitOptions{}); err != nil {
+		t.Fatalf("Failed to split. %s", err.Error())
+	}
+
+	expected := map[string][3]int{"01.wav": {0, 29, 30}, "02.wav": {30, 59, 30}}
+	for name, e := range expected {
+		first, last, n := frames(t, out[name].Bytes())
+		if first != e[0] || last != e[1] || n != e[2] {
+			t.Fatalf("%s: frames %d-%d (%d) recieved but %v expected.",
+				name, first, last, n, e)
+		}
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	sheet := `FILE "a.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+FILE "b.wav" WAVE
+  TRACK 02 AUDIO
+    INDEX 01 00:00:00
+`
+	mono := new(bytes.Buffer)
+	writeHeader(mono, format{44100, 1, 16, false}, 588)
+	mono.Write(make([]byte, 1176))
+
+	// FLAC STREAMINFO of one CD frame.
+	flac := bytes.NewBufferString("fLaC\x80\x00\x00\x22")
+	flac.Write(make([]byte, 10))
+	binary.Write(flac, binary.BigEndian, uint64(44100<<44|1<<41|15<<36|588))
+	flac.Write(make([]byte, 16))
+
+	tests := []struct {
+		fsys fstest.MapFS
+		err  string
+	}{
+		{fstest.MapFS{"a.wav": {Data: waveImage(1)}}, "Files not found: b.wav."},
+		{fstest.MapFS{"a.wav": {Data: waveImage(1)}, "b.wav": {Data: mono.Bytes()}},
+			"Format of b.wav differs from the format of a.wav."},
+		{fstest.MapFS{"a.wav": {Data: waveImage(1)}, "b.wav": {Data: flac.Bytes()}},
+			"Failed to open b.wav. File is neither PCM WAVE nor raw CD audio."},
+	}
+
+	for _, test := range tests {
+		s, err := cue.Parse(strings.NewReader(sheet))
+		if err != nil {
+			t.Fatalf("Failed to parse sheet. %s", err.Error())
+		}
+		_, err = Split(s, test.fsys, ".", outputs{}.create, SplitOptions{})
+		if err == nil || err.Error() != test.err {
+			t.Fatalf("Error %v recieved but %s expected.", err, test.err)
+		}
+	}
+}
+
+func TestSwapWriter(t *testing.T) {
+	input := make([]byte, 64)
+	for i := range input {
+		input[i] = byte(i)
+	}
+
+	buf := new(bytes.Buffer)
+	w := &swapWriter{w: buf}
+	for i, size := 0, 1; i < len(input); size = 3 - size {
+		n := min(size, len(input)-i)
+		if _, err := w.Write(input[i : i+n]); err != nil {
+			t.Fatalf("Failed to write. %s", err.Error())
+		}
+		i += n
+	}
+
+	out := buf.Bytes()
+	if len(out) != len(input) {
+		t.Fatalf("%d bytes written but %d expected.", len(out), len(input))
+	}
+	for i := 0; i < len(out); i += 2 {
+		if out[i] != input[i+1] || out[i+1] != input[i] {
+			t.Fatalf("Bytes %d-%d are %v but %v expected.", i, i+1, out[i:i+2], input[i:i+2])
+		}
+	}
+}
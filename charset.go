@@ -0,0 +1,413 @@
+package cue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Charset converts cue sheet text between some character encoding and UTF-8.
+// Implement it to support encodings which are not provided by the package.
+type Charset interface {
+	// Name returns charset name, e.g. "windows-1251".
+	Name() string
+	// Decode converts encoded data into UTF-8 string.
+	Decode(data []byte) (string, error)
+	// Encode converts UTF-8 string into the encoding.
+	Encode(text string) ([]byte, error)
+}
+
+// Supported charsets.
+var (
+	UTF8        Charset = utf8Charset{}
+	UTF16LE     Charset = utf16Charset{"UTF-16LE", binary.LittleEndian}
+	UTF16BE     Charset = utf16Charset{"UTF-16BE", binary.BigEndian}
+	Windows1251 Charset = &tableCharset{name: "windows-1251", table: &windows1251Table}
+	Windows1252 Charset = &tableCharset{name: "windows-1252", table: &windows1252Table}
+	ShiftJIS    Charset = &sjisCharset{}
+)
+
+// Byte order marks.
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// DetectCharset guesses charset of the cue sheet data. Byte order mark is
+// checked first, then UTF-16 and UTF-8 are recognised by their structure.
+// Legacy 8-bit data is checked to be Shift_JIS or Windows-1251
+// and falls back to Windows-1252 otherwise.
+func DetectCharset(data []byte) Charset {
+	if charset, _ := bomCharset(data); charset != nil {
+		return charset
+	}
+
+	if len(data) >= 2 && len(data)%2 == 0 {
+		// ASCII text in UTF-16 has zero every second byte.
+		var even, odd int
+		for i := 0; i < len(data); i += 2 {
+			if data[i] == 0 {
+				even++
+			}
+			if data[i+1] == 0 {
+				odd++
+			}
+		}
+		half := len(data) / 2
+		if odd*3 > half && even*10 < half {
+			return UTF16LE
+		}
+		if even*3 > half && odd*10 < half {
+			return UTF16BE
+		}
+	}
+
+	if utf8.Valid(data) {
+		return UTF8
+	}
+	if isShiftJIS(data) {
+		return ShiftJIS
+	}
+	if isWindows1251(data) {
+		return Windows1251
+	}
+
+	return Windows1252
+}
+
+// decodeText returns data decoded with the charset given or detected.
+// Byte order mark always takes precedence over the given charset.
+func decodeText(data []byte, charset Charset) (text string, used Charset, bom bool, err error) {
+	if c, n := bomCharset(data); c != nil {
+		charset = c
+		data = data[n:]
+		bom = true
+	} else if charset == nil {
+		charset = DetectCharset(data)
+	}
+
+	text, err = charset.Decode(data)
+	if err != nil {
+		return "", nil, false, err
+	}
+
+	return text, charset, bom, nil
+}
+
+// encodeText returns text encoded with the charset, prefixed with
+// byte order mark if requested.
+func encodeText(text string, charset Charset, bom bool) ([]byte, error) {
+	data, err := charset.Encode(text)
+	if err != nil {
+		return nil, err
+	}
+
+	if bom {
+		var mark []byte
+		switch charset {
+		case UTF8:
+			mark = bomUTF8
+		case UTF16LE:
+			mark = bomUTF16LE
+		case UTF16BE:
+			mark = bomUTF16BE
+		}
+		data = append(append([]byte{}, mark...), data...)
+	}
+
+	return data, nil
+}
+
+// bomCharset returns charset specified by the data byte order mark
+// and the mark length. Returns nil if there is no byte order mark.
+func bomCharset(data []byte) (Charset, int) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8, len(bomUTF8)
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE, len(bomUTF16LE)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE, len(bomUTF16BE)
+	}
+
+	return nil, 0
+}
+
+// isShiftJIS returns true if data is valid Shift_JIS text, most of double-byte
+// characters of which are kana or the first level kanji. Cyrillic and Western
+// text can look like valid Shift_JIS but uses other lead bytes.
+func isShiftJIS(data []byte) bool {
+	var common, total int
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		if c < 0x80 || (c >= 0xA1 && c <= 0xDF) {
+			continue
+		}
+		if !isSJISLead(c) || i+1 >= len(data) || !isSJISTrail(data[i+1]) {
+			return false
+		}
+		if c <= 0x9F {
+			common++
+		}
+		total++
+		i++
+	}
+
+	return total > 0 && common*2 >= total
+}
+
+// isWindows1251 returns true if data looks like Cyrillic text: letters with
+// 8-bit codes are used in words mostly without ASCII letters, while in
+// Western languages accented letters are single in a word.
+func isWindows1251(data []byte) bool {
+	var cyrillic, latin int
+
+	for _, word := range bytes.Fields(data) {
+		var high, ascii int
+		for _, c := range word {
+			if c >= 0xC0 {
+				high++
+			} else if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+				ascii++
+			}
+		}
+		if high == 0 {
+			continue
+		}
+		if high > ascii {
+			cyrillic++
+		} else {
+			latin++
+		}
+	}
+
+	return cyrillic > latin
+}
+
+// utf8Charset is UTF-8 charset.
+type utf8Charset struct{}
+
+// Name implements Charset interface.
+func (utf8Charset) Name() string {
+	return "UTF-8"
+}
+
+// Decode implements Charset interface.
+func (utf8Charset) Decode(data []byte) (string, error) {
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("Invalid UTF-8 text")
+	}
+
+	return string(data), nil
+}
+
+// Encode implements Charset interface.
+func (utf8Charset) Encode(text string) ([]byte, error) {
+	return []byte(text), nil
+}
+
+// utf16Charset is UTF-16 charset with the given byte order.
+type utf16Charset struct {
+	name  string
+	order binary.ByteOrder
+}
+
+// Name implements Charset interface.
+func (c utf16Charset) Name() string {
+	return c.name
+}
+
+// Decode implements Charset interface.
+func (c utf16Charset) Decode(data []byte) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("Odd length of %s text", c.name)
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = c.order.Uint16(data[i*2:])
+	}
+
+	return string(utf16.Decode(units)), nil
+}
+
+// Encode implements Charset interface.
+func (c utf16Charset) Encode(text string) ([]byte, error) {
+	units := utf16.Encode([]rune(text))
+	data := make([]byte, len(units)*2)
+	for i, u := range units {
+		c.order.PutUint16(data[i*2:], u)
+	}
+
+	return data, nil
+}
+
+// tableCharset is 8-bit charset with ASCII compatible lower half.
+type tableCharset struct {
+	name string
+	// Runes of 0x80-0xFF bytes.
+	table *[128]rune
+	// Reverse table built on the first Encode call.
+	once    sync.Once
+	reverse map[rune]byte
+}
+
+// Name implements Charset interface.
+func (c *tableCharset) Name() string {
+	return c.name
+}
+
+// Decode implements Charset interface.
+func (c *tableCharset) Decode(data []byte) (string, error) {
+	buf := new(bytes.Buffer)
+	for _, b := range data {
+		if b < 0x80 {
+			buf.WriteByte(b)
+		} else {
+			buf.WriteRune(c.table[b-0x80])
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// Encode implements Charset interface.
+func (c *tableCharset) Encode(text string) ([]byte, error) {
+	c.once.Do(func() {
+		c.reverse = make(map[rune]byte)
+		for i, r := range c.table {
+			if r != utf8.RuneError {
+				c.reverse[r] = byte(i + 0x80)
+			}
+		}
+	})
+
+	data := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r < 0x80 {
+			data = append(data, byte(r))
+			continue
+		}
+		b, ok := c.reverse[r]
+		if !ok {
+			return nil, fmt.Errorf("Character %q can't be encoded in %s", r, c.name)
+		}
+		data = append(data, b)
+	}
+
+	return data, nil
+}
+
+// sjisCharset is Shift_JIS (Windows code page 932) charset.
+type sjisCharset struct {
+	// Decoded sjisTable and its reverse, built on the first use.
+	once    sync.Once
+	table   [256][]rune
+	reverse map[rune][2]byte
+}
+
+// Name implements Charset interface.
+func (c *sjisCharset) Name() string {
+	return "Shift_JIS"
+}
+
+// init builds lookup tables.
+func (c *sjisCharset) init() {
+	c.once.Do(func() {
+		c.reverse = make(map[rune][2]byte)
+		for lead, row := range sjisTable {
+			c.table[lead] = []rune(row)
+			for i, r := range c.table[lead] {
+				if _, ok := c.reverse[r]; !ok && r != utf8.RuneError {
+					c.reverse[r] = [2]byte{byte(lead), sjisTrail(i)}
+				}
+			}
+		}
+	})
+}
+
+// Decode implements Charset interface.
+func (c *sjisCharset) Decode(data []byte) (string, error) {
+	c.init()
+
+	buf := new(bytes.Buffer)
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x80:
+			buf.WriteByte(b)
+		case b >= 0xA1 && b <= 0xDF:
+			// Half-width katakana.
+			buf.WriteRune(0xFF61 + rune(b-0xA1))
+		default:
+			if i+1 >= len(data) || !isSJISTrail(data[i+1]) || len(c.table[b]) == 0 {
+				return "", fmt.Errorf("Invalid Shift_JIS sequence at byte %d", i)
+			}
+			r := c.table[b][sjisTrailIndex(data[i+1])]
+			if r == utf8.RuneError {
+				return "", fmt.Errorf("Invalid Shift_JIS sequence at byte %d", i)
+			}
+			buf.WriteRune(r)
+			i++
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// Encode implements Charset interface.
+func (c *sjisCharset) Encode(text string) ([]byte, error) {
+	c.init()
+
+	data := make([]byte, 0, len(text))
+	for _, r := range text {
+		switch {
+		case r < 0x80:
+			data = append(data, byte(r))
+		case r >= 0xFF61 && r <= 0xFF9F:
+			data = append(data, byte(r-0xFF61+0xA1))
+		default:
+			code, ok := c.reverse[r]
+			if !ok {
+				return nil, fmt.Errorf("Character %q can't be encoded in Shift_JIS", r)
+			}
+			data = append(data, code[0], code[1])
+		}
+	}
+
+	return data, nil
+}
+
+// isSJISLead returns true if the byte starts Shift_JIS double-byte character.
+func isSJISLead(b byte) bool {
+	return (b >= 0x81 && b <= 0x9F) || (b >= 0xE0 && b <= 0xFC)
+}
+
+// isSJISTrail returns true if the byte can be the second byte
+// of Shift_JIS double-byte character.
+func isSJISTrail(b byte) bool {
+	return b >= 0x40 && b <= 0xFC && b != 0x7F
+}
+
+// sjisTrailIndex returns index of the trail byte in sjisTable row.
+func sjisTrailIndex(b byte) int {
+	if b < 0x7F {
+		return int(b) - 0x40
+	}
+
+	return int(b) - 0x41
+}
+
+// sjisTrail returns trail byte for the sjisTable row index.
+func sjisTrail(i int) byte {
+	if i < 0x3F {
+		return byte(i + 0x40)
+	}
+
+	return byte(i + 0x41)
+}
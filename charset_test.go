@@ -0,0 +1,109 @@
+package cue
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type charsetTest struct {
+	Data      []byte
+	Charset   Charset
+	Performer string
+	Title     string
+}
+
+func TestCharsetDetection(t *testing.T) {
+	var tests = []charsetTest{
+		{append([]byte{0xEF, 0xBB, 0xBF}, "PERFORMER \"Doro\"\nTITLE \"Doro\"\n"...),
+			UTF8, "Doro", "Doro"},
+		{[]byte("PERFORMER \"Кино\"\nTITLE \"Группа крови\""),
+			UTF8, "Кино", "Группа крови"},
+		{[]byte("PERFORMER \"\xca\xe8\xed\xee\"\nTITLE \"\xc3\xf0\xf3\xef\xef\xe0 \xea\xf0\xee\xe2\xe8\""),
+			Windows1251, "Кино", "Группа крови"},
+		{[]byte("PERFORMER \"Mot\xf6rhead\"\nTITLE \"Caf\xe9 del Mar\""),
+			Windows1252, "Motörhead", "Café del Mar"},
+		{[]byte("PERFORMER \"Utada\"\nTITLE \"\x89\x46\x91\xbd\x93\x63\x83\x71\x83\x4a\x83\x8b " +
+			"\x83\x74\x83\x40\x81\x5b\x83\x58\x83\x67\x81\x45\x83\x89\x83\x94\""),
+			ShiftJIS, "Utada", "宇多田ヒカル ファースト・ラヴ"},
+		{utf16Data(bomUTF16LE, UTF16LE, "PERFORMER \"Кино\"\r\nTITLE \"Группа крови\"\r\n"),
+			UTF16LE, "Кино", "Группа крови"},
+		{utf16Data(bomUTF16BE, UTF16BE, "PERFORMER \"Кино\"\r\nTITLE \"Группа крови\"\r\n"),
+			UTF16BE, "Кино", "Группа крови"},
+		{utf16Data(nil, UTF16LE, "PERFORMER \"Doro\"\nTITLE \"Doro\"\n"),
+			UTF16LE, "Doro", "Doro"},
+	}
+
+	for _, tt := range tests {
+		doc, err := ParseDocument(bytes.NewReader(tt.Data))
+		if err != nil {
+			t.Fatalf("Failed to parse %q. %s", tt.Data, err.Error())
+		}
+
+		if doc.Charset != tt.Charset {
+			t.Fatalf("Detected %s charset but %s expected", doc.Charset.Name(), tt.Charset.Name())
+		}
+		if doc.Sheet.Performer != tt.Performer || doc.Sheet.Title != tt.Title {
+			t.Fatalf("Decoded '%s' and '%s' but '%s' and '%s' expected",
+				doc.Sheet.Performer, doc.Sheet.Title, tt.Performer, tt.Title)
+		}
+
+		buf := new(bytes.Buffer)
+		if _, err := doc.WriteTo(buf); err != nil {
+			t.Fatalf("Failed to write document. %s", err.Error())
+		}
+		if !bytes.Equal(buf.Bytes(), tt.Data) {
+			t.Fatalf("Written %q but %q expected", buf.Bytes(), tt.Data)
+		}
+	}
+}
+
+func TestCharsetOption(t *testing.T) {
+	data := []byte("TITLE \"\xc3\xf0\xf3\xef\xef\xe0 \xea\xf0\xee\xe2\xe8\"")
+
+	doc, err := ParseWithOptions(bytes.NewReader(data), ParseOptions{Charset: Windows1252})
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	if doc.Sheet.Title != "Ãðóïïà êðîâè" {
+		t.Fatalf("Decoded '%s' with Windows-1252", doc.Sheet.Title)
+	}
+}
+
+func utf16Data(bom []byte, charset Charset, text string) []byte {
+	data, _ := charset.Encode(text)
+
+	return append(append([]byte{}, bom...), data...)
+}
+
+func TestCharsetTruncate(t *testing.T) {
+	var tests = []struct {
+		Title    string
+		Expected string
+	}{
+		{"a" + strings.Repeat("Группа крови ", 5), "a" + strings.Repeat("Группа крови ", 5)},
+		{strings.Repeat("Группа крови ", 7), strings.Repeat("Группа крови ", 6) + "Гр"},
+	}
+
+	for _, tt := range tests {
+		data, err := Windows1251.Encode("PERFORMER \"Кино\"\nTITLE \"" + tt.Title + "\"\n")
+		if err != nil {
+			t.Fatalf("Failed to encode. %s", err.Error())
+		}
+		doc, err := ParseDocument(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Failed to parse %q. %s", data, err.Error())
+		}
+		if doc.Charset != Windows1251 {
+			t.Fatalf("Detected %s charset but %s expected", doc.Charset.Name(), Windows1251.Name())
+		}
+		if doc.Sheet.Title != tt.Expected {
+			t.Fatalf("Decoded '%s' but '%s' expected", doc.Sheet.Title, tt.Expected)
+		}
+		truncated := tt.Title != tt.Expected
+		if truncated != (len(doc.Warnings) == 1 && errors.Is(doc.Warnings[0], ErrTooLong)) {
+			t.Fatalf("Unexpected warnings %v", doc.Warnings)
+		}
+	}
+}
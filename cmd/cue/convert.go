@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// converters export the sheet into the formats supported by the convert
+// command. Converters get the sheet input to probe audio files if needed.
+var converters = map[string]func(e *env, in *input, dir string, sheet *cue.CueSheet) error{
+	"json":       convertJSON,
+	"cue":        convertCue,
+	"ffmetadata": convertFFMetadata,
+}
+
+// runConvert exports the sheet to another format.
+func runConvert(e *env, args []string) error {
+	var jsonOut bool
+	fs := newFlagSet(e, "convert", &jsonOut)
+	to := fs.String("to", "", "output format: json, cue or ffmetadata")
+	dir := fs.String("dir", "", "directory of the audio files (default: sheet directory)")
+	name, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if jsonOut {
+		if *to != "" && *to != "json" {
+			return errUsage
+		}
+		*to = "json"
+	}
+	convert, ok := converters[*to]
+	if !ok {
+		return errUsage
+	}
+
+	in, err := readInput(e, name)
+	if err != nil {
+		return err
+	}
+	doc, err := in.parse(e)
+	if err != nil {
+		return err
+	}
+	sheet := doc.Sheet
+
+	return convert(e, in, *dir, sheet)
+}
+
+// convertJSON writes the sheet as JSON encoded by the cue package.
+func convertJSON(e *env, in *input, dir string, sheet *cue.CueSheet) error {
+	return writeJSON(e.stdout, sheet)
+}
+
+// convertCue writes the sheet in the canonical cue format.
+func convertCue(e *env, in *input, dir string, sheet *cue.CueSheet) error {
+	return cue.Write(e.stdout, sheet)
+}
+
+// convertFFMetadata writes the sheet as FFmpeg metadata file with track
+// chapters. The audio files are probed to find the disc length.
+func convertFFMetadata(e *env, in *input, dir string, sheet *cue.CueSheet) error {
+	lengths, err := in.lengths(dir, sheet)
+	if err != nil {
+		return err
+	}
+	toc, err := sheet.TOC(lengths...)
+	if err != nil {
+		return err
+	}
+
+	var tracks []*cue.Track
+	for i := range sheet.Files {
+		for j := range sheet.Files[i].Tracks {
+			tracks = append(tracks, &sheet.Files[i].Tracks[j])
+		}
+	}
+
+	w := e.stdout
+	io.WriteString(w, ";FFMETADATA1\n")
+	writeMetadata(w, "title", sheet.Title)
+	writeMetadata(w, "artist", sheet.Performer)
+	writeMetadata(w, "genre", sheet.Rem.Genre)
+	writeMetadata(w, "date", sheet.Rem.Date)
+	for i, t := range toc.Tracks {
+		end := toc.Leadout
+		if i+1 < len(toc.Tracks) {
+			end = toc.Tracks[i+1].Offset
+		}
+		fmt.Fprintf(w, "\n[CHAPTER]\nTIMEBASE=1/%d\nSTART=%d\nEND=%d\n",
+			cue.FramesPerSecond, t.Offset, end)
+		writeMetadata(w, "title", tracks[i].Title)
+		writeMetadata(w, "artist", tracks[i].Performer)
+	}
+
+	return nil
+}
+
+// ffmetadataEscaper escapes FFmpeg metadata special characters.
+var ffmetadataEscaper = strings.NewReplacer("\\", "\\\\", "=", "\\=", ";", "\\;",
+	"#", "\\#", "\n", "\\\n")
+
+// writeMetadata writes FFmpeg metadata key if the value is not empty.
+func writeMetadata(w io.Writer, key string, value string) {
+	if value != "" {
+		fmt.Fprintf(w, "%s=%s\n", key, ffmetadataEscaper.Replace(value))
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+)
+
+// discIDJSON is JSON output of the discid command.
+type discIDJSON struct {
+	CDDB           string `json:"cddb"`
+	MusicBrainz    string `json:"musicbrainz"`
+	MusicBrainzTOC string `json:"musicbrainz_toc"`
+	AccurateRip    string `json:"accuraterip"`
+}
+
+// runDiscID prints disc IDs. The audio files are probed to find
+// the disc length.
+func runDiscID(e *env, args []string) error {
+	var jsonOut bool
+	fs := newFlagSet(e, "discid", &jsonOut)
+	dir := fs.String("dir", "", "directory of the audio files (default: sheet directory)")
+	name, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	in, err := readInput(e, name)
+	if err != nil {
+		return err
+	}
+	doc, err := in.parse(e)
+	if err != nil {
+		return err
+	}
+	sheet := doc.Sheet
+	lengths, err := in.lengths(*dir, sheet)
+	if err != nil {
+		return err
+	}
+	toc, err := sheet.TOC(lengths...)
+	if err != nil {
+		return err
+	}
+
+	id := discIDJSON{
+		CDDB:           fmt.Sprintf("%08x", toc.CDDBDiscID()),
+		MusicBrainz:    toc.MusicBrainzID(),
+		MusicBrainzTOC: toc.MusicBrainzTOC(),
+		AccurateRip:    toc.AccurateRipID().Path(),
+	}
+	if jsonOut {
+		return writeJSON(e.stdout, &id)
+	}
+
+	fmt.Fprintf(e.stdout, "freedb:          %s\n", id.CDDB)
+	fmt.Fprintf(e.stdout, "MusicBrainz:     %s\n", id.MusicBrainz)
+	fmt.Fprintf(e.stdout, "MusicBrainz TOC: %s\n", id.MusicBrainzTOC)
+	fmt.Fprintf(e.stdout, "AccurateRip:     %s\n", id.AccurateRip)
+
+	return nil
+}
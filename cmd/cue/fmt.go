@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// fmtJSON is JSON output of the fmt command.
+type fmtJSON struct {
+	File    string `json:"file"`
+	Changed bool   `json:"changed"`
+	// Formatted sheet.
+	Sheet string `json:"sheet"`
+}
+
+// runFmt rewrites the sheet in the canonical form: commands in the
+// specification order, standard indentation and quotation. Text keeps
+// the source charset and byte order mark. Result is printed to the standard
+// output or written back to the file with -w flag. Sheets with problems
+// printed as warnings are not written back, as their canonical form loses
+// data.
+func runFmt(e *env, args []string) error {
+	var jsonOut bool
+	fs := newFlagSet(e, "fmt", &jsonOut)
+	write := fs.Bool("w", false, "write result to the source file instead of the standard output")
+	name, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	in, err := readInput(e, name)
+	if err != nil {
+		return err
+	}
+	if *write && in.name == "-" {
+		return errUsage
+	}
+	doc, err := in.parse(e)
+	if err != nil {
+		return err
+	}
+
+	text := new(bytes.Buffer)
+	if err := cue.Write(text, doc.Sheet); err != nil {
+		return err
+	}
+	// Unknown commands and commands overridden by the following ones
+	// are not kept in the sheet.
+	dropped := commandsCount(doc) - strings.Count(text.String(), "\n")
+	if dropped > 0 {
+		fmt.Fprintf(e.stderr, "%s: warning: %d commands are dropped by formatting\n", in.name, dropped)
+	}
+	// Lenient parsing drops or changes values which break the specification.
+	if *write && (len(doc.Warnings) > 0 || dropped > 0) {
+		return fmt.Errorf("%s: sheet is not written, formatting would lose data.", in.name)
+	}
+
+	// Document without source lines is written in the canonical form
+	// with the document's charset.
+	canonical := *doc
+	canonical.Lines = nil
+	buf := new(bytes.Buffer)
+	if _, err := canonical.WriteTo(buf); err != nil {
+		return err
+	}
+	changed := !bytes.Equal(buf.Bytes(), in.data)
+
+	if *write && changed {
+		fi, err := os.Stat(in.name)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(in.name, buf.Bytes(), fi.Mode()); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case jsonOut:
+		return writeJSON(e.stdout, &fmtJSON{in.name, changed, text.String()})
+	case *write:
+		return nil
+	default:
+		_, err := e.stdout.Write(buf.Bytes())
+		return err
+	}
+}
+
+// commandsCount returns number of the document's source lines with commands.
+func commandsCount(doc *cue.Document) int {
+	n := 0
+	for _, line := range doc.Lines {
+		if line.Command != "" {
+			n++
+		}
+	}
+
+	return n
+}
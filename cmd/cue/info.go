@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// infoJSON is JSON output of the info command.
+type infoJSON struct {
+	Title     string          `json:"title,omitempty"`
+	Performer string          `json:"performer,omitempty"`
+	Genre     string          `json:"genre,omitempty"`
+	Date      string          `json:"date,omitempty"`
+	Files     []string        `json:"files"`
+	Tracks    []trackInfoJSON `json:"tracks"`
+}
+
+type trackInfoJSON struct {
+	Number    int    `json:"number"`
+	Title     string `json:"title,omitempty"`
+	Performer string `json:"performer,omitempty"`
+	File      string `json:"file"`
+	Start     string `json:"start"`
+	// Empty if the track end is unknown.
+	Length string `json:"length,omitempty"`
+	Pregap string `json:"pregap,omitempty"`
+}
+
+// runInfo prints sheet metadata and tracks table. Lengths of the tracks
+// are known if the audio files are found.
+func runInfo(e *env, args []string) error {
+	var jsonOut bool
+	fs := newFlagSet(e, "info", &jsonOut)
+	dir := fs.String("dir", "", "directory of the audio files (default: sheet directory)")
+	name, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	in, err := readInput(e, name)
+	if err != nil {
+		return err
+	}
+	doc, err := in.parse(e)
+	if err != nil {
+		return err
+	}
+	sheet := doc.Sheet
+
+	// Lengths are optional, last tracks of the files have no end without them.
+	lengths, err := in.lengths(*dir, sheet)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "cue: track lengths are unknown. %s\n", err.Error())
+	}
+
+	tracks, err := trackInfos(sheet, lengths)
+	if err != nil {
+		return err
+	}
+	info := infoJSON{
+		Title:     sheet.Title,
+		Performer: sheet.Performer,
+		Genre:     sheet.Rem.Genre,
+		Date:      sheet.Rem.Date,
+		Files:     []string{},
+		Tracks:    tracks,
+	}
+	for _, file := range sheet.Files {
+		info.Files = append(info.Files, file.Name)
+	}
+
+	if jsonOut {
+		return writeJSON(e.stdout, &info)
+	}
+
+	fmt.Fprintf(e.stdout, "Title:     %s\n", info.Title)
+	fmt.Fprintf(e.stdout, "Performer: %s\n", info.Performer)
+	if info.Genre != "" {
+		fmt.Fprintf(e.stdout, "Genre:     %s\n", info.Genre)
+	}
+	if info.Date != "" {
+		fmt.Fprintf(e.stdout, "Date:      %s\n", info.Date)
+	}
+	fmt.Fprintln(e.stdout)
+
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tTitle\tPerformer\tFile\tStart\tLength\tPregap")
+	for _, t := range info.Tracks {
+		fmt.Fprintf(w, "%02d\t%s\t%s\t%s\t%s\t%s\t%s\n", t.Number, t.Title, t.Performer,
+			t.File, t.Start, dash(t.Length), dash(t.Pregap))
+	}
+
+	return w.Flush()
+}
+
+// trackInfos returns description of every sheet track. Lengths and pregaps
+// which can't be computed are left empty.
+func trackInfos(sheet *cue.CueSheet, lengths []cue.Time) ([]trackInfoJSON, error) {
+	spans, err := sheet.TrackSpans(lengths)
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := []trackInfoJSON{}
+	for _, span := range spans {
+		track := trackInfoJSON{
+			Number:    span.Track.Number,
+			Title:     span.Track.Title,
+			Performer: span.Track.Performer,
+			File:      sheet.Files[span.File].Name,
+			Start:     span.Start.String(),
+		}
+		if span.EndKnown {
+			track.Length = span.Length().String()
+		}
+		if span.PregapKnown && span.Pregap != (cue.Time{}) {
+			track.Pregap = span.Pregap.String()
+		}
+		tracks = append(tracks, track)
+	}
+
+	return tracks, nil
+}
+
+// dash returns "-" for empty strings.
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,202 @@
+// Command cue inspects, validates, formats and converts cue sheets.
+//
+// Usage:
+//
+//	cue <command> [flags] [file]
+//
+// The commands are:
+//
+//	info      print sheet metadata and tracks table
+//	validate  check the sheet and print diagnostics
+//	fmt       rewrite the sheet in the canonical form
+//	convert   export the sheet to another format
+//	discid    print freedb, MusicBrainz and AccurateRip disc IDs
+//
+// The sheet is read from the file or from the standard input if the file
+// is omitted or is "-". Every command accepts -json flag to print its
+// output as JSON.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// command is a cue subcommand.
+type command struct {
+	// Short description.
+	summary string
+	// Flags and arguments description.
+	usage string
+	run   func(env *env, args []string) error
+}
+
+var commands = map[string]command{
+	"info":     {"print sheet metadata and tracks table", "[-json] [-dir dir] [file]", runInfo},
+	"validate": {"check the sheet and print diagnostics", "[-json] [-lenient] [file]", runValidate},
+	"fmt":      {"rewrite the sheet in the canonical form", "[-json] [-w] [file]", runFmt},
+	"convert":  {"export the sheet to another format", "[-json] [-to format] [-dir dir] [file]", runConvert},
+	"discid":   {"print freedb, MusicBrainz and AccurateRip disc IDs", "[-json] [-dir dir] [file]", runDiscID},
+}
+
+// errUsage is returned for wrong command line arguments.
+var errUsage = errors.New("Wrong usage.")
+
+// errFailed is returned when the command has already reported
+// the problem and should exit with non-zero status.
+var errFailed = errors.New("Failed.")
+
+// env is the command environment.
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command line and returns exit status.
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	e := &env{stdin, stdout, stderr}
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "cue: unknown command %q\n", args[0])
+		usage(stderr)
+		return 2
+	}
+
+	err := cmd.run(e, args[1:])
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp):
+		fmt.Fprintf(stderr, "usage: cue %s %s\n", args[0], cmd.usage)
+		return 2
+	case errors.Is(err, errFailed):
+		return 1
+	default:
+		fmt.Fprintf(stderr, "cue: %s\n", err.Error())
+		return 1
+	}
+}
+
+// usage prints the list of commands.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: cue <command> [flags] [file]\n\ncommands:\n")
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-9s %s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet returns flag set of the command with the -json flag.
+func newFlagSet(e *env, name string, jsonOut *bool) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.BoolVar(jsonOut, "json", false, "print output as JSON")
+
+	return fs
+}
+
+// parseFlags parses command flags. At most one file argument is accepted.
+func parseFlags(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", errUsage
+	}
+	if fs.NArg() > 1 {
+		return "", errUsage
+	}
+
+	return fs.Arg(0), nil
+}
+
+// input is the sheet source.
+type input struct {
+	// File name, "-" for the standard input.
+	name string
+	// Directory of the sheet, audio files are looked for there.
+	dir  string
+	data []byte
+}
+
+// readInput reads the named file or the standard input.
+func readInput(e *env, name string) (*input, error) {
+	if name == "" || name == "-" {
+		data, err := io.ReadAll(e.stdin)
+		if err != nil {
+			return nil, err
+		}
+
+		return &input{"-", ".", data}, nil
+	}
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &input{name, filepath.Dir(name), data}, nil
+}
+
+// parse parses the input in the lenient mode, so real-world sheets
+// are accepted. Problems accepted are printed as warnings.
+func (in *input) parse(e *env) (*cue.Document, error) {
+	doc, err := cue.ParseWithOptions(bytes.NewReader(in.data), cue.ParseOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", in.name, err.Error())
+	}
+	for _, w := range doc.Warnings {
+		fmt.Fprintf(e.stderr, "%s:%d:%d: warning: %s\n", in.name, w.Line, w.Column, w.Err.Error())
+	}
+
+	return doc, nil
+}
+
+// lengths returns lengths of the sheet audio files found in the dir.
+// If the dir is empty the sheet directory is used.
+func (in *input) lengths(dir string, sheet *cue.CueSheet) ([]cue.Time, error) {
+	if dir == "" {
+		dir = in.dir
+	}
+	fsys := os.DirFS(dir)
+	paths, err := cue.Resolve(fsys, ".", sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	// Probe the files found.
+	resolved := *sheet
+	resolved.Files = make([]cue.File, len(sheet.Files))
+	for i, file := range sheet.Files {
+		resolved.Files[i] = cue.File{Name: paths[i], Type: file.Type}
+	}
+
+	return cue.ProbeLengths(fsys, &resolved)
+}
+
+// writeJSON writes v as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
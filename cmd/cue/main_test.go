@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// gapsAppended is a sheet with track 2 pregap stored in the first file.
+const gapsAppended = `FILE "01.wav" WAVE
+  TRACK 01 AUDIO
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    INDEX 00 04:00:00
+FILE "02.wav" WAVE
+    INDEX 01 00:00:00
+  TRACK 03 AUDIO
+    INDEX 00 02:59:00
+    INDEX 01 03:00:00
+`
+
+const sheet = `REM GENRE Rock
+PERFORMER "Band"
+TITLE "Album"
+FILE "image.bin" BINARY
+  TRACK 01 AUDIO
+    TITLE "One"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Two=2"
+    INDEX 00 00:10:00
+    INDEX 01 00:12:00
+`
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "album.cue")
+	if err := os.WriteFile(name, []byte(sheet), 0644); err != nil {
+		t.Fatalf("Failed to write sheet. %s", err.Error())
+	}
+	// 00:20:00 of raw audio.
+	audio, err := os.Create(filepath.Join(dir, "image.bin"))
+	if err != nil {
+		t.Fatalf("Failed to create audio. %s", err.Error())
+	}
+	audio.Truncate(1500 * 2352)
+	audio.Close()
+
+	tests := []struct {
+		args   []string
+		stdin  string
+		status int
+		// Substrings expected in the output.
+		output []string
+	}{
+		{[]string{}, "", 2, []string{"usage: cue <command>"}},
+		{[]string{"foo"}, "", 2, []string{"unknown command"}},
+		{[]string{"info", "a", "b"}, "", 2, []string{"usage: cue info"}},
+		{[]string{"info", name}, "", 0,
+			[]string{"Title:     Album", "02  Two=2  ", "00:12:00  00:08:00  00:02:00"}},
+		{[]string{"info", "-json"}, sheet, 0,
+			[]string{`"genre": "Rock"`, `"start": "00:12:00"`, `"pregap": "00:02:00"`}},
+		{[]string{"info"}, gapsAppended, 0,
+			[]string{"01.wav  00:00:00  04:00:00  -\n",
+				"02.wav  00:00:00  02:59:00  -\n",
+				"02.wav  03:00:00  -         00:01:00\n",
+				"track lengths are unknown"}},
+		{[]string{"info", "-json"}, gapsAppended, 0,
+			[]string{`"number": 2,
+      "file": "02.wav",
+      "start": "00:00:00",
+      "length": "02:59:00"
+    }`}},
+		{[]string{"validate", name}, "", 0, nil},
+		{[]string{"validate"}, gapsAppended, 0, nil},
+		{[]string{"validate"}, "track 01 audio\nTITLE Foo Bar\n", 1,
+			[]string{"-:1:1: error:"}},
+		{[]string{"validate", "-lenient", "-json"}, "FILE a.flac FLAC\nTRACK 01 AUDIO\nINDEX 01 00:00:00\n", 0,
+			[]string{`"valid": true`, `"severity": "warning"`, `"line": 1`}},
+		{[]string{"validate", "-lenient"}, "FILE a.wav WAVE\nTRACK 01 AUDIO\n", 1,
+			[]string{"-: error: Track 1 has no INDEX 01."}},
+		{[]string{"fmt"}, "title  foo\n", 0,
+			[]string{"TITLE \"foo\"\n", "-:1:1: warning: Command 'title' should be in upper case"}},
+		{[]string{"fmt", "-json", name}, "", 0, []string{`"changed": false`}},
+		{[]string{"fmt", "-w"}, sheet, 2, nil},
+		{[]string{"convert", "-to", "xml", name}, "", 2, nil},
+		{[]string{"convert", "-json"}, sheet, 0,
+			[]string{`"Type": "BINARY"`, `"DataType": "AUDIO"`, `"Time": "00:10:00"`}},
+		{[]string{"convert", "-to", "cue"}, sheet, 0, []string{sheet}},
+		{[]string{"convert", "-to", "ffmetadata", name}, "", 0,
+			[]string{";FFMETADATA1\ntitle=Album\nartist=Band\ngenre=Rock\n",
+				"START=900\nEND=1500\ntitle=Two\\=2\n"}},
+		{[]string{"discid", name}, "", 0,
+			[]string{"freedb:          07001402", "AccurateRip:     "}},
+		{[]string{"discid", "-json", "-dir", dir}, sheet, 0,
+			[]string{`"musicbrainz_toc": "1+2+1650+150+1050"`}},
+		{[]string{"discid"}, sheet, 1, []string{"Files not found: image.bin."}},
+	}
+
+	for _, test := range tests {
+		out := new(bytes.Buffer)
+		status := run(test.args, strings.NewReader(test.stdin), out, out)
+		if status != test.status {
+			t.Fatalf("%v: status %d recieved but %d expected.\n%s",
+				test.args, status, test.status, out.String())
+		}
+		for _, s := range test.output {
+			if !strings.Contains(out.String(), s) {
+				t.Fatalf("%v: output\n%s\ndoesn't contain\n%s", test.args, out.String(), s)
+			}
+		}
+	}
+}
+
+// encode returns text encoded with the charset.
+func encode(charset cue.Charset, text string) string {
+	data, _ := charset.Encode(text)
+
+	return string(data)
+}
+
+func TestFmtWrite(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		input  string
+		status int
+		output string
+	}{
+		{"TITLE   foo\n", 0, "TITLE \"foo\"\n"},
+		// Lenient parsing drops the unknown command and changes the file type.
+		{"TITLE foo\nFOO bar\n", 1, "TITLE foo\nFOO bar\n"},
+		{"FILE a.ogg OGG\nTRACK 01 AUDIO\nINDEX 01 00:00:00\n", 1,
+			"FILE a.ogg OGG\nTRACK 01 AUDIO\nINDEX 01 00:00:00\n"},
+		// The first TITLE is overridden and long PERFORMER is truncated.
+		{"TITLE foo\nTITLE bar\n", 1, "TITLE foo\nTITLE bar\n"},
+		{"PERFORMER " + strings.Repeat("a", 81) + "\n", 1, "PERFORMER " + strings.Repeat("a", 81) + "\n"},
+		// Charset and byte order mark are kept.
+		{encode(cue.Windows1251, "TITLE   \"Группа крови\"\n"), 0,
+			encode(cue.Windows1251, "TITLE \"Группа крови\"\n")},
+		{"\xff\xfe" + encode(cue.UTF16LE, "TITLE   \"Кино\"\r\n"), 0,
+			"\xff\xfe" + encode(cue.UTF16LE, "TITLE \"Кино\"\n")},
+	}
+
+	for i, test := range tests {
+		name := filepath.Join(dir, fmt.Sprintf("%d.cue", i))
+		if err := os.WriteFile(name, []byte(test.input), 0644); err != nil {
+			t.Fatalf("Failed to write sheet. %s", err.Error())
+		}
+
+		out := new(bytes.Buffer)
+		if status := run([]string{"fmt", "-w", name}, nil, out, out); status != test.status {
+			t.Fatalf("Status %d recieved but %d expected. %s", status, test.status, out.String())
+		}
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatalf("Failed to read sheet. %s", err.Error())
+		}
+		if string(data) != test.output {
+			t.Fatalf("Sheet %q recieved but %q expected.", data, test.output)
+		}
+	}
+}
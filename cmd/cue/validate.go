@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// diagnosticJSON is a problem found by the validate command.
+type diagnosticJSON struct {
+	// Zero if the problem is not bound to a line.
+	Line     int    `json:"line,omitempty"`
+	Column   int    `json:"column,omitempty"`
+	Severity string `json:"severity"`
+	Command  string `json:"command,omitempty"`
+	Message  string `json:"message"`
+}
+
+// validateJSON is JSON output of the validate command.
+type validateJSON struct {
+	File        string           `json:"file"`
+	Valid       bool             `json:"valid"`
+	Diagnostics []diagnosticJSON `json:"diagnostics"`
+}
+
+// runValidate checks the sheet and prints all the problems found. Sheet is
+// checked against the specification, with -lenient flag common deviations
+// are reported as warnings. Exits with non-zero status if errors are found.
+func runValidate(e *env, args []string) error {
+	var jsonOut bool
+	fs := newFlagSet(e, "validate", &jsonOut)
+	lenient := fs.Bool("lenient", false, "report deviations from the specification as warnings")
+	name, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	in, err := readInput(e, name)
+	if err != nil {
+		return err
+	}
+
+	result := validateJSON{File: in.name, Diagnostics: []diagnosticJSON{}}
+	doc, err := cue.ParseWithOptions(bytes.NewReader(in.data),
+		cue.ParseOptions{MaxErrors: -1, Strict: !*lenient})
+
+	var list cue.ErrorList
+	var perr *cue.ParseError
+	switch {
+	case errors.As(err, &list):
+		for _, pe := range list {
+			result.Diagnostics = append(result.Diagnostics, newDiagnostic(pe, "error"))
+		}
+	case errors.As(err, &perr):
+		result.Diagnostics = append(result.Diagnostics, newDiagnostic(perr, "error"))
+	case err != nil:
+		return err
+	}
+	if doc != nil {
+		for _, pe := range doc.Warnings {
+			result.Diagnostics = append(result.Diagnostics, newDiagnostic(pe, "warning"))
+		}
+		if err == nil {
+			for _, number := range missingIndex(doc.Sheet) {
+				result.Diagnostics = append(result.Diagnostics, diagnosticJSON{
+					Severity: "error",
+					Message:  fmt.Sprintf("Track %d has no INDEX 01.", number),
+				})
+			}
+		}
+	}
+
+	result.Valid = true
+	for _, d := range result.Diagnostics {
+		if d.Severity == "error" {
+			result.Valid = false
+		}
+	}
+
+	if jsonOut {
+		if err := writeJSON(e.stdout, &result); err != nil {
+			return err
+		}
+	} else {
+		for _, d := range result.Diagnostics {
+			if d.Line > 0 {
+				fmt.Fprintf(e.stdout, "%s:%d:%d: %s: %s\n", in.name, d.Line, d.Column, d.Severity, d.Message)
+			} else {
+				fmt.Fprintf(e.stdout, "%s: %s: %s\n", in.name, d.Severity, d.Message)
+			}
+		}
+	}
+
+	if !result.Valid {
+		return errFailed
+	}
+
+	return nil
+}
+
+// newDiagnostic returns diagnostic for the parse error.
+func newDiagnostic(err *cue.ParseError, severity string) diagnosticJSON {
+	return diagnosticJSON{
+		Line:     err.Line,
+		Column:   err.Column,
+		Severity: severity,
+		Command:  err.Command,
+		Message:  err.Err.Error(),
+	}
+}
+
+// missingIndex returns numbers of the tracks without INDEX 01.
+func missingIndex(sheet *cue.CueSheet) []int {
+	var numbers []int
+	for _, file := range sheet.Files {
+		for _, track := range file.Tracks {
+			found := false
+			for _, index := range track.Indexes {
+				found = found || index.Number == 1
+			}
+			if !found {
+				numbers = append(numbers, track.Number)
+			}
+		}
+	}
+
+	return numbers
+}
@@ -3,8 +3,6 @@
 package cue
 
 import (
-	"bufio"
-	"errors"
 	"fmt"
 	"io"
 	"regexp"
@@ -13,7 +11,7 @@ import (
 )
 
 // commandParser is the function for parsing one command.
-type commandParser func(params []string, sheet *CueSheet) error
+type commandParser func(p *parser, params []string) error
 
 // commandParserDesctiptor describes command parser.
 type commandParserDescriptor struct {
@@ -39,60 +37,263 @@ var parsersMap = map[string]commandParserDescriptor{
 	"TRACK":      {2, parseTrack},
 }
 
+// fileTypes maps FILE command type names to FileType values.
+var fileTypes = map[string]FileType{
+	"BINARY":   FileTypeBinary,
+	"MOTOROLA": FileTypeMotorola,
+	"AIFF":     FileTypeAiff,
+	"WAVE":     FileTypeWave,
+	"MP3":      FileTypeMp3,
+	"FLAC":     FileTypeFlac,
+	"APE":      FileTypeApe,
+	"WAVPACK":  FileTypeWavPack,
+}
+
+// codecFileTypes is the set of file types which are not defined by the
+// specification, they are accepted in the lenient mode only.
+var codecFileTypes = map[FileType]bool{
+	FileTypeFlac:    true,
+	FileTypeApe:     true,
+	FileTypeWavPack: true,
+}
+
+// trackDataTypes maps TRACK command datatype names to TrackDataType values.
+var trackDataTypes = map[string]TrackDataType{
+	"AUDIO":      DataTypeAudio,
+	"CDG":        DataTypeCdg,
+	"MODE1/2048": DataTypeMode1_2048,
+	"MODE1/2352": DataTypeMode1_2352,
+	"MODE2/2336": DataTypeMode2_2336,
+	"MODE2/2352": DataTypeMode2_2352,
+	"CDI/2336":   DataTypeCdi_2336,
+	"CDI/2352":   DataTypeCdi_2352,
+}
+
+// trackFlags maps FLAGS command parameters to TrackFlag values.
+var trackFlags = map[string]TrackFlag{
+	"DCP":  TrackFlagDcp,
+	"4CH":  TrackFlag4ch,
+	"PRE":  TrackFlagPre,
+	"SCMS": TrackFlagScms,
+}
+
+// Maximum length of TITLE, PERFORMER and SONGWRITER values.
+const maxTextLength = 80
+
+// textCommands is the set of commands with a single text parameter.
+// In the lenient mode unquoted text with spaces is accepted for them.
+var textCommands = map[string]bool{
+	"CDTEXTFILE": true,
+	"FILE":       true,
+	"PERFORMER":  true,
+	"SONGWRITER": true,
+	"TITLE":      true,
+}
+
+// ParseOptions configures cue sheet parsing.
+type ParseOptions struct {
+	// Maximum number of errors to collect before parsing is stopped.
+	// Zero or one stops parsing on the first error, negative value
+	// means errors number is not limited.
+	MaxErrors int
+	// Strict mode follows the cue sheet specification. In the lenient mode
+	// (Strict is false) common deviations produced by real-world rippers are
+	// accepted and reported as Document.Warnings: lower case commands,
+	// unquoted text with spaces, TRACK without FILE, non-sequential track
+	// and index numbers, first index of the file not at 00:00:00, unknown
+	// and non-standard (FLAC, APE, WAVPACK) file types, invalid CATALOG
+	// and ISRC values. Unknown commands are skipped. TITLE, PERFORMER and
+	// SONGWRITER values longer than 80 characters are truncated and reported
+	// as warnings in both modes.
+	Strict bool
+	// Charset of the data. If it's nil the charset is detected with
+	// DetectCharset. Byte order mark found in the data overrides Charset.
+	Charset Charset
+}
+
+// parser keeps state of the cue sheet being parsed.
+type parser struct {
+	sheet  *CueSheet
+	strict bool
+	// Problems of the current command which are not errors in the lenient mode.
+	pending []error
+	// Warnings found in the whole sheet.
+	warnings []*ParseError
+}
+
 // Parse parses cue-sheet data (file) and returns filled CueSheet struct.
+// Parsing is strict and stops on the first error.
 func Parse(reader io.Reader) (sheet *CueSheet, err error) {
-	sheet = new(CueSheet)
+	doc, err := ParseDocument(reader)
+	if err != nil {
+		return nil, err
+	}
 
-	rd := bufio.NewReader(reader)
-	lineNumber := 0
+	return doc.Sheet, nil
+}
 
-	for buf, _, err := rd.ReadLine(); err != io.EOF; buf, _, err = rd.ReadLine() {
-		if err != nil {
-			return nil, err
-		}
+// ParseDocument parses cue-sheet data (file) and returns a Document,
+// which keeps all the source lines alongside the filled CueSheet struct.
+// Parsing is strict and stops on the first error.
+func ParseDocument(reader io.Reader) (doc *Document, err error) {
+	doc, err = ParseWithOptions(reader, ParseOptions{Strict: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
 
-		line := strings.TrimSpace(string(buf))
+// ParseWithOptions parses cue-sheet data (file) according to the given options.
+// If opts allows to collect more than one error, the lines with errors are
+// skipped, partially filled document is returned with ErrorList error.
+// Otherwise parsing stops on the first error and *ParseError is returned.
+func ParseWithOptions(reader io.Reader, opts ParseOptions) (doc *Document, err error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
 
-		// Skip empty lines.
-		if len(line) == 0 {
+	text, charset, bom, err := decodeText(data, opts.Charset)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{sheet: new(CueSheet), strict: opts.Strict}
+	lines := splitLines(text)
+	counts := make(map[lineKey]int)
+	var errs ErrorList
+
+	for i := range lines {
+		line := &lines[i]
+
+		cmd, perr := p.parseLine(line, i+1)
+		if perr != nil {
+			if opts.MaxErrors >= 0 && opts.MaxErrors <= 1 {
+				return nil, perr
+			}
+			errs = append(errs, perr)
+			if len(errs) == opts.MaxErrors {
+				break
+			}
 			continue
 		}
-
-		cmd, params, err := parseCommand(line)
-		if err != nil {
-			return nil, fmt.Errorf("Line %d. %s", err.Error())
+		if cmd == "" {
+			continue
 		}
 
-		lineNumber++
+		// Remember which sheet entity the line describes.
+		key := lineKey{scope: commandScope(cmd, p.sheet), name: keyName(cmd, tokenValues(line.params))}
+		key.n = counts[key]
+		counts[key]++
+		line.key = &key
+	}
+
+	doc = newDocument(p.sheet, lines)
+	doc.Warnings = p.warnings
+	doc.Charset = charset
+	doc.bom = bom
+	if len(errs) != 0 {
+		return doc, errs
+	}
+
+	return doc, nil
+}
+
+// parseLine parses one source line filling the sheet.
+// Returns parsed command or empty string for the empty or skipped line.
+func (p *parser) parseLine(line *Line, number int) (string, *ParseError) {
+	text := strings.TrimSpace(line.Command + line.Params)
+
+	// Skip empty lines.
+	if len(text) == 0 {
+		return "", nil
+	}
+
+	cmd, params, err := lexCommand(text)
+	if err != nil {
+		return "", newParseError(line, number, cmd, nil, err)
+	}
+	line.params = params
+
+	p.pending = nil
+	name, err := p.applyCommand(cmd, params)
+	for _, warning := range p.pending {
+		p.warnings = append(p.warnings, newParseError(line, number, cmd, params, warning))
+	}
+	if err != nil {
+		return "", newParseError(line, number, cmd, params, err)
+	}
 
-		parserDescriptor, ok := parsersMap[cmd]
+	return name, nil
+}
+
+// applyCommand fills the sheet with the command.
+// Returns command name or empty string if the command was skipped.
+func (p *parser) applyCommand(cmd string, params []token) (string, error) {
+	parserDescriptor, ok := parsersMap[cmd]
+	if !ok && !p.strict {
+		name := strings.ToUpper(cmd)
+		parserDescriptor, ok = parsersMap[name]
 		if !ok {
-			return nil, fmt.Errorf("Line %d. Unknown command '%s'", lineNumber, cmd)
+			p.warn(newCommandError(ErrUnknownCommand, -1, "Unknown command '%s'", cmd))
+			return "", nil
 		}
+		p.warn(newCommandError(ErrSyntax, -1, "Command '%s' should be in upper case", cmd))
+		cmd = name
+	}
+	if !ok {
+		return "", newCommandError(ErrUnknownCommand, -1, "Unknown command '%s'", cmd)
+	}
 
-		paramsExpected := parserDescriptor.paramsCount
-		paramsRecieved := len(params)
-		if paramsExpected != -1 && paramsExpected != paramsRecieved {
-			return nil, fmt.Errorf("Line %d. Command %s: recieved %d parameters but %d expected",
-				lineNumber, cmd, paramsRecieved, paramsExpected)
-		}
+	paramsExpected := parserDescriptor.paramsCount
+	paramsRecieved := len(params)
+	if paramsExpected != -1 && paramsExpected < paramsRecieved && !p.strict && textCommands[cmd] {
+		p.warn(newCommandError(ErrParamCount, -1, "Command %s: text with spaces should be quoted", cmd))
+		params = joinTokens(params, paramsRecieved-paramsExpected+1)
+		paramsRecieved = len(params)
+	}
+	if paramsExpected != -1 && paramsExpected != paramsRecieved {
+		return "", newCommandError(ErrParamCount, -1, "Command %s: recieved %d parameters but %d expected",
+			cmd, paramsRecieved, paramsExpected)
+	}
 
-		err = parserDescriptor.parser(params, sheet)
-		if err != nil {
-			return nil, fmt.Errorf("Line %d. Failed to parse %s command. %s", lineNumber, cmd, err.Error())
-		}
+	err := parserDescriptor.parser(p, tokenValues(params))
+	if err != nil {
+		return "", wrapCommandError(err, "Failed to parse %s command. %s", cmd, err.Error())
+	}
+
+	return cmd, nil
+}
+
+// warn saves the problem as a warning of the current command.
+func (p *parser) warn(err error) {
+	p.pending = append(p.pending, err)
+}
+
+// relax returns the given error in the strict mode.
+// In the lenient mode the error is saved as warning and nil is returned,
+// so the command parsing goes on.
+func (p *parser) relax(err error) error {
+	if p.strict {
+		return err
 	}
+	p.warn(err)
 
-	return sheet, nil
+	return nil
 }
 
 // parseCatalog parsers CATALOG command.
-func parseCatalog(params []string, sheet *CueSheet) error {
+func parseCatalog(p *parser, params []string) error {
+	sheet := p.sheet
 	num := params[0]
 
 	matched, _ := regexp.MatchString("^[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]$", num)
 	if !matched {
-		return fmt.Errorf("%s is not valid catalog number", params)
+		err := p.relax(newCommandError(ErrBadValue, 0, "%s is not valid catalog number", num))
+		if err != nil {
+			return err
+		}
 	}
 
 	sheet.Catalog = num
@@ -101,8 +302,8 @@ func parseCatalog(params []string, sheet *CueSheet) error {
 }
 
 // parseCdTextFile parsers CDTEXTFILE command.
-func parseCdTextFile(params []string, sheet *CueSheet) error {
-	sheet.CdTextFile = params[0]
+func parseCdTextFile(p *parser, params []string) error {
+	p.sheet.CdTextFile = params[0]
 
 	return nil
 }
@@ -110,20 +311,27 @@ func parseCdTextFile(params []string, sheet *CueSheet) error {
 // parseFile parsers FILE command.
 // params[0] -- fileName
 // params[1] -- fileType
-func parseFile(params []string, sheet *CueSheet) error {
+func parseFile(p *parser, params []string) error {
+	sheet := p.sheet
+
 	// Type parser function.
 	parseFileType := func(t string) (fileType FileType, err error) {
-		var types = map[string]FileType{
-			"BINARY":   FileTypeBinary,
-			"MOTOROLA": FileTypeMotorola,
-			"AIFF":     FileTypeAiff,
-			"WAVE":     FileTypeWave,
-			"MP3":      FileTypeMp3,
+		fileType, ok := fileTypes[t]
+		if !ok && !p.strict {
+			fileType, ok = fileTypes[strings.ToUpper(t)]
+			if ok {
+				p.warn(newCommandError(ErrBadValue, 1, "File type %s should be in upper case", t))
+			} else {
+				// Unknown types are compressed audio files in the most cases.
+				p.warn(newCommandError(ErrBadValue, 1, "Unknown file type %s", t))
+				fileType = FileTypeWave
+				ok = true
+			}
 		}
-
-		fileType, ok := types[t]
 		if !ok {
-			err = fmt.Errorf("Unknown file type %s", t)
+			err = newCommandError(ErrBadValue, 1, "Unknown file type %s", t)
+		} else if codecFileTypes[fileType] {
+			err = p.relax(newCommandError(ErrBadValue, 1, "Non-standard file type %s", t))
 		}
 
 		return
@@ -144,16 +352,17 @@ func parseFile(params []string, sheet *CueSheet) error {
 }
 
 // parseFlags parsers FLAGS command.
-func parseFlags(params []string, sheet *CueSheet) error {
+func parseFlags(p *parser, params []string) error {
+	sheet := p.sheet
+
 	flagParser := func(flag string) (trackFlag TrackFlag, err error) {
-		var flags = map[string]TrackFlag{
-			"DCP":  TrackFlagDcp,
-			"4CH":  TrackFlag4ch,
-			"PRE":  TrackFlagPre,
-			"SCMS": TrackFlagScms,
+		trackFlag, ok := trackFlags[flag]
+		if !ok && !p.strict {
+			trackFlag, ok = trackFlags[strings.ToUpper(flag)]
+			if ok {
+				p.warn(newCommandError(ErrBadValue, -1, "Track flag %s should be in upper case", flag))
+			}
 		}
-
-		trackFlag, ok := flags[flag]
 		if !ok {
 			err = fmt.Errorf("Unknown track flag %s", flag)
 		}
@@ -163,13 +372,18 @@ func parseFlags(params []string, sheet *CueSheet) error {
 
 	track := getCurrentTrack(sheet)
 	if track == nil {
-		return errors.New("TRACK command should appears before FLAGS command")
+		return newCommandError(ErrOrder, -1, "TRACK command should appears before FLAGS command")
 	}
 
-	for _, flagStr := range params {
+	for i, flagStr := range params {
 		flag, err := flagParser(flagStr)
 		if err != nil {
-			return err
+			err = p.relax(newCommandError(ErrBadValue, i, "%s", err.Error()))
+			if err != nil {
+				return err
+			}
+			// Skip unknown flag.
+			continue
 		}
 		track.Flags = append(track.Flags, flag)
 	}
@@ -178,31 +392,37 @@ func parseFlags(params []string, sheet *CueSheet) error {
 }
 
 // parseIndex parsers INDEX command.
-func parseIndex(params []string, sheet *CueSheet) error {
+func parseIndex(p *parser, params []string) error {
+	sheet := p.sheet
+
 	min, sec, frames, err := parseTime(params[1])
 	if err != nil {
-		return fmt.Errorf("Failed to parse index start time. %s", err.Error())
+		return newCommandError(ErrBadTime, 1, "Failed to parse index start time. %s", err.Error())
 	}
 
 	number, err := strconv.Atoi(params[0])
 	if err != nil {
-		return fmt.Errorf("Failed to parse index number. %s", err.Error())
+		return newCommandError(ErrBadValue, 0, "Failed to parse index number. %s", err.Error())
 	}
 
 	// All index numbers must be between 0 and 99 inclusive.
 	if number < 0 || number > 99 {
-		return errors.New("Index number should be in 0..99 interval")
+		return newCommandError(ErrBadValue, 0, "Index number should be in 0..99 interval")
 	}
 
-	track := getCurrentTrack(sheet)
+	// INDEX right after FILE continues the last track of the previous file.
+	track, offset := getLastTrack(sheet)
 	if track == nil {
-		return fmt.Errorf("TRACK command should appears before INDEX command")
+		return newCommandError(ErrOrder, -1, "TRACK command should appears before INDEX command")
 	}
 
 	// The first index of a file must start at 00:00:00.
-	if getFileLastIndex(getCurrentFile(sheet)) == nil {
+	if getFileLastIndex(sheet, len(sheet.Files)-1) == nil {
 		if min+sec+frames != 0 {
-			return errors.New("First track index must start at 00:00:00")
+			err := p.relax(newCommandError(ErrBadTime, 1, "First track index must start at 00:00:00"))
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -210,40 +430,54 @@ func parseIndex(params []string, sheet *CueSheet) error {
 	if len(track.Indexes) == 0 {
 		// The first index must be 0 or 1.
 		if number >= 2 {
-			return errors.New("First track index should has 0 or 1 inxed number")
+			err := p.relax(newCommandError(ErrSequence, 0, "First track index should has 0 or 1 inxed number"))
+			if err != nil {
+				return err
+			}
 		}
 	} else {
 		// All other indexes being sequential to the first one.
 		numberExpected := track.Indexes[len(track.Indexes)-1].Number + 1
 		if numberExpected != number {
-			return fmt.Errorf("Expected %d index number but %d recieved", numberExpected, number)
+			err := p.relax(newCommandError(ErrSequence, 0, "Expected %d index number but %d recieved",
+				numberExpected, number))
+			if err != nil {
+				return err
+			}
 		}
 	}
 
-	index := Index{Number: number, Time: Time{min, sec, frames}}
+	index := Index{Number: number, Time: Time{min, sec, frames}, FileOffset: offset}
 	track.Indexes = append(track.Indexes, index)
 
 	return nil
 }
 
 // parseIsrc parsers ISRC command.
-func parseIsrc(params []string, sheet *CueSheet) error {
+func parseIsrc(p *parser, params []string) error {
+	sheet := p.sheet
 	isrc := params[0]
 
 	track := getCurrentTrack(sheet)
 	if track == nil {
-		return errors.New("TRACK command should appears before ISRC command")
+		return newCommandError(ErrOrder, -1, "TRACK command should appears before ISRC command")
 	}
 
 	if len(track.Indexes) != 0 {
-		return errors.New("ISRC command must be specified before INDEX command")
+		err := p.relax(newCommandError(ErrOrder, -1, "ISRC command must be specified before INDEX command"))
+		if err != nil {
+			return err
+		}
 	}
 
 	re := "^[0-9a-zA-z][0-9a-zA-z][0-9a-zA-z][0-9a-zA-z][0-9a-zA-z]" +
 		"[0-9][0-9][0-9][0-9][0-9][0-9][0-9]$"
 	matched, _ := regexp.MatchString(re, isrc)
 	if !matched {
-		return fmt.Errorf("%s is not valid ISRC number", isrc)
+		err := p.relax(newCommandError(ErrBadValue, 0, "%s is not valid ISRC number", isrc))
+		if err != nil {
+			return err
+		}
 	}
 
 	track.Isrc = isrc
@@ -252,9 +486,10 @@ func parseIsrc(params []string, sheet *CueSheet) error {
 }
 
 // parsePerformer parsers PERFORMER command.
-func parsePerformer(params []string, sheet *CueSheet) error {
-	// Limit this field length up to 80 characters.
-	performer := stringTruncate(params[0], 80)
+func parsePerformer(p *parser, params []string) error {
+	sheet := p.sheet
+
+	performer := p.text("PERFORMER", params[0])
 	track := getCurrentTrack(sheet)
 
 	if track == nil {
@@ -269,15 +504,16 @@ func parsePerformer(params []string, sheet *CueSheet) error {
 }
 
 // parsePostgap parsers POSTGAP command.
-func parsePostgap(params []string, sheet *CueSheet) error {
+func parsePostgap(p *parser, params []string) error {
+	sheet := p.sheet
 	track := getCurrentTrack(sheet)
 	if track == nil {
-		return errors.New("POSTGAP command must appear after a TRACK command")
+		return newCommandError(ErrOrder, -1, "POSTGAP command must appear after a TRACK command")
 	}
 
 	min, sec, frames, err := parseTime(params[0])
 	if err != nil {
-		return fmt.Errorf("Failed to parse postgap time. %s", err.Error())
+		return newCommandError(ErrBadTime, 0, "Failed to parse postgap time. %s", err.Error())
 	}
 
 	track.Postgap = Time{min, sec, frames}
@@ -286,19 +522,23 @@ func parsePostgap(params []string, sheet *CueSheet) error {
 }
 
 // parsePregap parsers PREGAP command.
-func parsePregap(params []string, sheet *CueSheet) error {
+func parsePregap(p *parser, params []string) error {
+	sheet := p.sheet
 	track := getCurrentTrack(sheet)
 	if track == nil {
-		return errors.New("PREGAP command must appear after a TRACK command")
+		return newCommandError(ErrOrder, -1, "PREGAP command must appear after a TRACK command")
 	}
 
 	if len(track.Indexes) != 0 {
-		return errors.New("PREGAP command must appear before any INDEX command")
+		err := p.relax(newCommandError(ErrOrder, -1, "PREGAP command must appear before any INDEX command"))
+		if err != nil {
+			return err
+		}
 	}
 
 	min, sec, frames, err := parseTime(params[0])
 	if err != nil {
-		return fmt.Errorf("Failed to parse pregap time. %s", err.Error())
+		return newCommandError(ErrBadTime, 0, "Failed to parse pregap time. %s", err.Error())
 	}
 
 	track.Pregap = Time{min, sec, frames}
@@ -307,16 +547,29 @@ func parsePregap(params []string, sheet *CueSheet) error {
 }
 
 // parseRem parsers REM command.
-func parseRem(params []string, sheet *CueSheet) error {
-	sheet.Comments = append(sheet.Comments, strings.Join(params, " "))
+// Comments after TRACK command belong to the track.
+func parseRem(p *parser, params []string) error {
+	rem := &p.sheet.Rem
+	comments := &p.sheet.Comments
+	if track := getCurrentTrack(p.sheet); track != nil {
+		rem = &track.Rem
+		comments = &track.Comments
+	}
+
+	if isRemMetadata(params) {
+		rem.set(params[0], params[1:])
+	} else {
+		*comments = append(*comments, strings.Join(params, " "))
+	}
 
 	return nil
 }
 
 // parseSongWriter parsers SONGWRITER command.
-func parseSongWriter(params []string, sheet *CueSheet) error {
-	// Limit this field length up to 80 characters.
-	songwriter := stringTruncate(params[0], 80)
+func parseSongWriter(p *parser, params []string) error {
+	sheet := p.sheet
+
+	songwriter := p.text("SONGWRITER", params[0])
 	track := getCurrentTrack(sheet)
 
 	if track == nil {
@@ -329,9 +582,10 @@ func parseSongWriter(params []string, sheet *CueSheet) error {
 }
 
 // parseTitle parsers TITLE command.
-func parseTitle(params []string, sheet *CueSheet) error {
-	// Limit this field length up to 80 characters.
-	title := stringTruncate(params[0], 80)
+func parseTitle(p *parser, params []string) error {
+	sheet := p.sheet
+
+	title := p.text("TITLE", params[0])
 	track := getCurrentTrack(sheet)
 
 	if track == nil {
@@ -345,11 +599,30 @@ func parseTitle(params []string, sheet *CueSheet) error {
 	return nil
 }
 
+// text returns text parameter limited up to 80 characters.
+// Truncated text is reported as warning.
+func (p *parser) text(cmd string, value string) string {
+	text := stringTruncate(value, maxTextLength)
+	if text != value {
+		p.warn(newCommandError(ErrTooLong, 0, "%s is longer than %d characters and is truncated",
+			cmd, maxTextLength))
+	}
+
+	return text
+}
+
 // parseTrack parses TRACK command.
-func parseTrack(params []string, sheet *CueSheet) error {
+func parseTrack(p *parser, params []string) error {
+	sheet := p.sheet
+
 	// TRACK command should be after FILE command.
 	if len(sheet.Files) == 0 {
-		return fmt.Errorf("Unexpected TRACK command. FILE command expected first.")
+		err := p.relax(newCommandError(ErrOrder, -1, "Unexpected TRACK command. FILE command expected first."))
+		if err != nil {
+			return err
+		}
+		// Tracks without FILE command are stored in unnamed file.
+		sheet.Files = append(sheet.Files, File{})
 	}
 
 	numberStr := params[0]
@@ -357,20 +630,15 @@ func parseTrack(params []string, sheet *CueSheet) error {
 
 	// Type parser function.
 	parseDataType := func(t string) (dataType TrackDataType, err error) {
-		var types = map[string]TrackDataType{
-			"AUDIO":      DataTypeAudio,
-			"CDG":        DataTypeCdg,
-			"MODE1/2048": DataTypeMode1_2048,
-			"MODE1/2352": DataTypeMode1_2352,
-			"MODE2/2336": DataTypeMode2_2336,
-			"MODE2/2352": DataTypeMode2_2352,
-			"CDI/2336":   DataTypeCdi_2336,
-			"CDI/2352":   DataTypeCdi_2352,
+		dataType, ok := trackDataTypes[t]
+		if !ok && !p.strict {
+			dataType, ok = trackDataTypes[strings.ToUpper(t)]
+			if ok {
+				p.warn(newCommandError(ErrBadValue, 1, "Track datatype %s should be in upper case", t))
+			}
 		}
-
-		dataType, ok := types[t]
 		if !ok {
-			err = fmt.Errorf("Unknown track datatype %s", t)
+			err = newCommandError(ErrBadValue, 1, "Unknown track datatype %s", t)
 		}
 
 		return
@@ -378,10 +646,10 @@ func parseTrack(params []string, sheet *CueSheet) error {
 
 	number, err := strconv.Atoi(numberStr)
 	if err != nil {
-		return fmt.Errorf("Failed to parse track number parameter. %s", err.Error())
+		return newCommandError(ErrBadValue, 0, "Failed to parse track number parameter. %s", err.Error())
 	}
-	if number < 1 {
-		return fmt.Errorf("Failed to parse track number parameter. Value should be in 1..99 range.")
+	if number < 1 || number > 99 {
+		return newCommandError(ErrBadValue, 0, "Failed to parse track number parameter. Value should be in 1..99 range.")
 	}
 
 	dataType, err := parseDataType(dataTypeStr)
@@ -397,9 +665,13 @@ func parseTrack(params []string, sheet *CueSheet) error {
 
 	// But all track numbers after the first must be sequential.
 	if len(file.Tracks) > 0 {
-		if file.Tracks[len(file.Tracks)-1].Number != number-1 {
-			return fmt.Errorf("Expected track number %d, but %d recieved.",
-				number-1, number)
+		numberExpected := file.Tracks[len(file.Tracks)-1].Number + 1
+		if numberExpected != number {
+			err := p.relax(newCommandError(ErrSequence, 0, "Expected track number %d, but %d recieved.",
+				numberExpected, number))
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -408,6 +680,25 @@ func parseTrack(params []string, sheet *CueSheet) error {
 	return nil
 }
 
+// commandScope returns scope of the just parsed command.
+func commandScope(cmd string, sheet *CueSheet) scope {
+	switch cmd {
+	case "CATALOG", "CDTEXTFILE":
+		return discScope
+	case "FILE":
+		return scope{len(sheet.Files) - 1, -1}
+	case "INDEX":
+		return lastTrackScope(sheet)
+	}
+
+	file := getCurrentFile(sheet)
+	if file == nil || len(file.Tracks) == 0 {
+		return discScope
+	}
+
+	return scope{len(sheet.Files) - 1, len(file.Tracks) - 1}
+}
+
 // getCurrentFile returns file object started with the last FILE command.
 // Returns nil if there is no any File objects.
 func getCurrentFile(sheet *CueSheet) *File {
@@ -433,14 +724,44 @@ func getCurrentTrack(sheet *CueSheet) *Track {
 	return &file.Tracks[len(file.Tracks)-1]
 }
 
-// getFileLastIndex returns last index for the given file.
-// Returns nil if file has no any indexes.
-func getFileLastIndex(file *File) *Index {
-	for i := len(file.Tracks) - 1; i >= 0; i-- {
-		track := &file.Tracks[i]
+// getLastTrack returns the last track of the sheet, which can belong to
+// one of the previous files, and offset of the current file from the
+// track's one. Returns nil if there is no any Track object avaliable.
+func getLastTrack(sheet *CueSheet) (*Track, int) {
+	s := lastTrackScope(sheet)
+	if s == discScope {
+		return nil, 0
+	}
+
+	return &sheet.Files[s.file].Tracks[s.track], len(sheet.Files) - 1 - s.file
+}
+
+// lastTrackScope returns scope of the last track of the sheet.
+// Returns discScope if there is no any Track object avaliable.
+func lastTrackScope(sheet *CueSheet) scope {
+	for i := len(sheet.Files) - 1; i >= 0; i-- {
+		if n := len(sheet.Files[i].Tracks); n > 0 {
+			return scope{i, n - 1}
+		}
+	}
 
-		for j := len(track.Indexes) - 1; j >= 0; j-- {
-			return &track.Indexes[j]
+	return discScope
+}
+
+// getFileLastIndex returns last index for the n-th file, including indexes
+// of the tracks continued from the previous files.
+// Returns nil if file has no any indexes.
+func getFileLastIndex(sheet *CueSheet, n int) *Index {
+	for i := n; i >= 0; i-- {
+		tracks := sheet.Files[i].Tracks
+		for j := len(tracks) - 1; j >= 0; j-- {
+			track := &tracks[j]
+
+			for k := len(track.Indexes) - 1; k >= 0; k-- {
+				if i+track.Indexes[k].FileOffset == n {
+					return &track.Indexes[k]
+				}
+			}
 		}
 	}
 
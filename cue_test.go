@@ -1,8 +1,10 @@
 package cue
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -19,5 +21,56 @@ func TestPackage(t *testing.T) {
 		t.Fatalf("Failed to parse file. %s", err.Error())
 	}
 
-	fmt.Printf("Sheet: %V\n", sheet)
+	fmt.Printf("Sheet: %v\n", sheet)
+}
+
+func TestParseLenient(t *testing.T) {
+	input := "catalog 12345\n" +
+		"title Some Album\n" +
+		"FOO bar\n" +
+		"  track 01 audio\n" +
+		"    INDEX 01 00:00:00\n" +
+		"FILE \"b.flac\" FLAC\n" +
+		"  TRACK 03 AUDIO\n" +
+		"    FLAGS DCP XYZ\n" +
+		"    INDEX 01 00:00:33\n" +
+		"  TRACK 05 AUDIO\n" +
+		"    INDEX 01 02:00:00\n" +
+		"    ISRC ABC\n"
+
+	if _, err := Parse(strings.NewReader(input)); err == nil {
+		t.Fatalf("Strict parsing should fail")
+	}
+
+	doc, err := ParseWithOptions(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse file. %s", err.Error())
+	}
+
+	sheet := doc.Sheet
+	if sheet.Catalog != "12345" || sheet.Title != "Some Album" {
+		t.Fatalf("Disc fields are not filled: %+v", sheet)
+	}
+	if len(sheet.Files) != 2 || sheet.Files[0].Name != "" || len(sheet.Files[0].Tracks) != 1 {
+		t.Fatalf("Track without FILE is not saved: %+v", sheet.Files)
+	}
+	file := sheet.Files[1]
+	if file.Type != FileTypeFlac || len(file.Tracks) != 2 {
+		t.Fatalf("Second file is not filled: %+v", file)
+	}
+	if len(file.Tracks[0].Flags) != 1 || file.Tracks[1].Isrc != "ABC" {
+		t.Fatalf("Tracks are not filled: %+v", file.Tracks)
+	}
+
+	kinds := []error{ErrSyntax, ErrBadValue, ErrSyntax, ErrParamCount, ErrUnknownCommand,
+		ErrSyntax, ErrOrder, ErrBadValue, ErrBadValue, ErrBadValue, ErrBadTime, ErrSequence,
+		ErrOrder, ErrBadValue}
+	if len(doc.Warnings) != len(kinds) {
+		t.Fatalf("%d warnings found but %d expected: %v", len(doc.Warnings), len(kinds), doc.Warnings)
+	}
+	for i, warning := range doc.Warnings {
+		if !errors.Is(warning, kinds[i]) {
+			t.Fatalf("Warning '%s' is not %v", warning.Error(), kinds[i])
+		}
+	}
 }
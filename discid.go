@@ -0,0 +1,159 @@
+package cue
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Length of the disc lead-in in frames. First track starts after it.
+const leadInFrames = 150
+
+// Gap between sessions of the enhanced CD in frames: lead-out and lead-in
+// of the first session and the second session pregap.
+const sessionGapFrames = 11400
+
+// CDDBDiscID returns freedb (CDDB) disc ID of the single-file sheet computed
+// from the track INDEX 01 offsets. leadout is the file length. For multi-file
+// sheets build the TOC with all the file lengths.
+func (sheet *CueSheet) CDDBDiscID(leadout Time) (uint32, error) {
+	toc, err := sheet.TOC(leadout)
+	if err != nil {
+		return 0, err
+	}
+
+	return toc.CDDBDiscID(), nil
+}
+
+// CheckDiscID returns true if disc ID computed with CDDBDiscID
+// matches REM DISCID value.
+func (sheet *CueSheet) CheckDiscID(leadout Time) (bool, error) {
+	if sheet.Rem.DiscID == "" {
+		return false, fmt.Errorf("Sheet has no REM DISCID.")
+	}
+	expected, err := strconv.ParseUint(sheet.Rem.DiscID, 16, 32)
+	if err != nil {
+		return false, fmt.Errorf("Failed to parse REM DISCID. %s", err.Error())
+	}
+
+	id, err := sheet.CDDBDiscID(leadout)
+	if err != nil {
+		return false, err
+	}
+
+	return id == uint32(expected), nil
+}
+
+// CDDBDiscID returns freedb (CDDB) disc ID. All the tracks including
+// data ones are counted.
+func (toc *TOC) CDDBDiscID() uint32 {
+	var n int
+	for _, track := range toc.Tracks {
+		for s := (track.Offset + leadInFrames) / FramesPerSecond; s > 0; s /= 10 {
+			n += s % 10
+		}
+	}
+	first := (toc.Tracks[0].Offset + leadInFrames) / FramesPerSecond
+	last := (toc.Leadout + leadInFrames) / FramesPerSecond
+
+	return uint32(n%255)<<24 | uint32(last-first)<<8 | uint32(len(toc.Tracks))
+}
+
+// audioSession returns tracks and lead-out of the first session of the
+// enhanced CD. Data tracks following audio ones are in the second session,
+// which ends the first session 11400 frames before the data track.
+func (toc *TOC) audioSession() ([]TOCTrack, int) {
+	tracks := toc.Tracks
+	leadout := toc.Leadout
+	for len(tracks) > 1 && tracks[len(tracks)-1].Data && !tracks[len(tracks)-2].Data {
+		leadout = tracks[len(tracks)-1].Offset - sessionGapFrames
+		tracks = tracks[:len(tracks)-1]
+	}
+
+	return tracks, leadout
+}
+
+// MusicBrainzTOC returns TOC in the MusicBrainz "toc" query parameter format:
+// first track number, last track number, lead-out and track offsets
+// separated with "+". Data tracks of the enhanced CD are excluded.
+func (toc *TOC) MusicBrainzTOC() string {
+	tracks, leadout := toc.audioSession()
+
+	parts := []string{
+		strconv.Itoa(tracks[0].Number),
+		strconv.Itoa(tracks[len(tracks)-1].Number),
+		strconv.Itoa(leadout + leadInFrames),
+	}
+	for _, track := range tracks {
+		parts = append(parts, strconv.Itoa(track.Offset+leadInFrames))
+	}
+
+	return strings.Join(parts, "+")
+}
+
+// MusicBrainzID returns MusicBrainz disc ID. Data tracks
+// of the enhanced CD are excluded.
+func (toc *TOC) MusicBrainzID() string {
+	tracks, leadout := toc.audioSession()
+
+	var offsets [100]int
+	offsets[0] = leadout + leadInFrames
+	for _, track := range tracks {
+		if track.Number >= 1 && track.Number <= 99 {
+			offsets[track.Number] = track.Offset + leadInFrames
+		}
+	}
+
+	h := sha1.New()
+	fmt.Fprintf(h, "%02X%02X", tracks[0].Number, tracks[len(tracks)-1].Number)
+	for _, offset := range offsets {
+		fmt.Fprintf(h, "%08X", offset)
+	}
+	id := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	return strings.NewReplacer("+", ".", "/", "_", "=", "-").Replace(id)
+}
+
+// AccurateRipID identifies the disc in the AccurateRip database.
+type AccurateRipID struct {
+	// Number of audio tracks.
+	Tracks int
+	// Sum of the audio track offsets and lead-out.
+	ID1 uint32
+	// Sum of the audio track offsets multiplied by track numbers.
+	ID2 uint32
+	// freedb disc ID.
+	CDDB uint32
+}
+
+// AccurateRipID returns AccurateRip disc identifiers. Data tracks
+// are not counted but define the lead-out position.
+func (toc *TOC) AccurateRipID() AccurateRipID {
+	id := AccurateRipID{CDDB: toc.CDDBDiscID()}
+
+	for _, track := range toc.Tracks {
+		if track.Data {
+			continue
+		}
+		offset := uint32(track.Offset)
+		id.ID1 += offset
+		if offset == 0 {
+			offset = 1
+		}
+		id.ID2 += offset * uint32(track.Number)
+		id.Tracks++
+	}
+	id.ID1 += uint32(toc.Leadout)
+	id.ID2 += uint32(toc.Leadout) * uint32(id.Tracks+1)
+
+	return id
+}
+
+// Path returns path of the disc's AccurateRip database file, e.g.
+// "5/6/4/dBAR-010-00103465-008217f9-840a130a.bin".
+func (id AccurateRipID) Path() string {
+	return fmt.Sprintf("%x/%x/%x/dBAR-%03d-%08x-%08x-%08x.bin",
+		id.ID1&0xF, id.ID1>>4&0xF, id.ID1>>8&0xF, id.Tracks, id.ID1, id.ID2, id.CDDB)
+}
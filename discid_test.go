@@ -0,0 +1,135 @@
+package cue
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCDDBDiscID(t *testing.T) {
+	file, err := os.Open("test.cue")
+	if err != nil {
+		t.Fatalf("Failed to open file. %s", err.Error())
+	}
+	defer file.Close()
+
+	sheet, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Failed to parse file. %s", err.Error())
+	}
+
+	leadout := Time{42, 59, 0}
+	id, err := sheet.CDDBDiscID(leadout)
+	if err != nil {
+		t.Fatalf("Failed to compute disc ID. %s", err.Error())
+	}
+	if id != 0x840A130A {
+		t.Fatalf("Computed %08X disc ID but 840A130A expected", id)
+	}
+
+	if ok, err := sheet.CheckDiscID(leadout); err != nil || !ok {
+		t.Fatalf("REM DISCID doesn't match. %v", err)
+	}
+	sheet.Files[0].Tracks[1].Indexes[0].Time = Time{4, 35, 0}
+	if ok, _ := sheet.CheckDiscID(leadout); ok {
+		t.Fatalf("Edited sheet matches REM DISCID")
+	}
+}
+
+func TestCDDBDiscIDMultiFile(t *testing.T) {
+	sheet, err := Parse(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	if _, err := sheet.CDDBDiscID(Time{10, 0, 0}); err == nil {
+		t.Fatalf("Disc ID of multi-file sheet is computed")
+	}
+}
+
+// newTOC returns TOC for the offsets and lead-out given
+// in the MusicBrainz format, i.e. with the lead-in counted.
+func newTOC(leadout int, offsets ...int) *TOC {
+	toc := &TOC{Leadout: leadout - leadInFrames}
+	for i, offset := range offsets {
+		toc.Tracks = append(toc.Tracks, TOCTrack{Number: i + 1, Offset: offset - leadInFrames})
+	}
+
+	return toc
+}
+
+func TestMusicBrainzID(t *testing.T) {
+	toc := newTOC(95462, 150, 15363, 32314, 46592, 63414, 80489)
+
+	if s := toc.MusicBrainzTOC(); s != "1+6+95462+150+15363+32314+46592+63414+80489" {
+		t.Fatalf("Unexpected TOC string %s", s)
+	}
+	if id := toc.MusicBrainzID(); id != "49HHV7Eb8UKF3aQiNmu1GR8vKTY-" {
+		t.Fatalf("Computed %s disc ID", id)
+	}
+
+	// Enhanced CD: the same audio session followed by the data track.
+	enhanced := newTOC(200000, 150, 15363, 32314, 46592, 63414, 80489, 95462+11400)
+	enhanced.Tracks[6].Data = true
+	if s := enhanced.MusicBrainzTOC(); s != toc.MusicBrainzTOC() {
+		t.Fatalf("Data track is not excluded: %s", s)
+	}
+	if id := enhanced.MusicBrainzID(); id != toc.MusicBrainzID() {
+		t.Fatalf("Data track is not excluded from disc ID %s", id)
+	}
+	if enhanced.CDDBDiscID() == toc.CDDBDiscID() {
+		t.Fatalf("Data track is excluded from CDDB disc ID")
+	}
+}
+
+func TestSheetTOC(t *testing.T) {
+	input := "FILE \"a.bin\" BINARY\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 MODE1/2352\n" +
+		"    INDEX 01 03:00:00\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	toc, err := sheet.TOC(Time{10, 0, 0})
+	if err != nil {
+		t.Fatalf("Failed to build TOC. %s", err.Error())
+	}
+	if toc.Tracks[0].Data || !toc.Tracks[1].Data || toc.Tracks[1].Offset != 13500 {
+		t.Fatalf("Unexpected TOC %+v", toc)
+	}
+	if s := toc.MusicBrainzTOC(); s != "1+1+2250+150" {
+		t.Fatalf("Unexpected TOC string %s", s)
+	}
+
+	if _, err := sheet.TOC(Time{2, 0, 0}); err == nil {
+		t.Fatalf("Lead-out before the last track is accepted")
+	}
+}
+
+func TestAccurateRipID(t *testing.T) {
+	// Ladyhawke - Ladyhawke (0602517818866): enhanced CD with 12 audio
+	// tracks and a data track. The TOC and disc IDs are taken from the
+	// whipper test suite, where they are checked against freedb,
+	// MusicBrainz and the AccurateRip URL requested by EAC.
+	toc := newTOC(210535, 150, 15687, 31841, 51016, 66616, 81352, 99559,
+		116070, 133243, 149997, 161710, 177832, 207256)
+	toc.Tracks[12].Data = true
+
+	if id := toc.CDDBDiscID(); id != 0xc60af50d {
+		t.Fatalf("Computed %08x CDDB disc ID", id)
+	}
+	if id := toc.MusicBrainzID(); id != "KnpGsLhvH.lPrNc1PBL21lb9Bg4-" {
+		t.Fatalf("Computed %s MusicBrainz disc ID", id)
+	}
+
+	id := toc.AccurateRipID()
+	etalon := AccurateRipID{Tracks: 12, ID1: 0x0013bd5a, ID2: 0x00b8d489, CDDB: 0xc60af50d}
+	if id != etalon {
+		t.Fatalf("Computed %+v but %+v expected", id, etalon)
+	}
+	if p := id.Path(); p != "a/5/d/dBAR-012-0013bd5a-00b8d489-c60af50d.bin" {
+		t.Fatalf("Unexpected path %s", p)
+	}
+}
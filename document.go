@@ -0,0 +1,311 @@
+package cue
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Line is a single source line of a cue sheet.
+type Line struct {
+	// Leading whitespace.
+	Indent string
+	// Command name as it is written in the source. Empty for blank lines.
+	Command string
+	// Raw parameters text as it is written in the source,
+	// including whitespace separating it from the command.
+	Params string
+	// Line ending: "\n", "\r\n" or empty string for the last line of the file.
+	Ending string
+
+	// Parsed parameters.
+	params []token
+	// Command the line was parsed to. nil for blank lines.
+	key *lineKey
+}
+
+// lineKey identifies a command in the sheet: n-th command with the given name
+// in the scope.
+type lineKey struct {
+	scope scope
+	name  string
+	n     int
+}
+
+// Document is a cue sheet together with its source lines.
+// Document written back with WriteTo keeps formatting, blank lines and order
+// of the source commands, only lines with values changed in the Sheet are
+// rewritten. Commands are identified by their position, e.g. the second
+// INDEX of the third track in the first file. Document without Lines
+// is written in the canonical form like with Write.
+type Document struct {
+	// Parsed cue sheet. It can be modified before writing the document back.
+	Sheet *CueSheet
+	// Source lines.
+	Lines []Line
+	// Problems accepted in the lenient parsing mode and truncated texts.
+	Warnings []*ParseError
+	// Charset of the source data, the document is written back in it.
+	Charset Charset
+	// Source data starts with byte order mark.
+	bom bool
+	// Command parameters right after parsing.
+	parsed map[lineKey][]string
+}
+
+// newDocument returns document for the just parsed sheet.
+func newDocument(sheet *CueSheet, lines []Line) *Document {
+	// Just parsed sheet can always be written.
+	cmds, _ := sheetCommands(sheet)
+	parsed := make(map[lineKey][]string, len(cmds))
+	for i, key := range commandKeys(cmds) {
+		parsed[key] = cmds[i].params
+	}
+
+	return &Document{Sheet: sheet, Lines: lines, Charset: UTF8, parsed: parsed}
+}
+
+// String returns the line text without line ending.
+func (line *Line) String() string {
+	return line.Indent + line.Command + line.Params
+}
+
+// WriteTo implements io.WriterTo interface. Unchanged lines are written as is,
+// lines with changed values are rewritten keeping indentation, command case,
+// quotation style and line ending. New commands are inserted in the canonical
+// order and commands removed from the sheet are dropped. Text is encoded with
+// the document's Charset.
+func (doc *Document) WriteTo(w io.Writer) (n int64, err error) {
+	cmds, err := sheetCommands(doc.Sheet)
+	if err != nil {
+		return 0, err
+	}
+	keys := commandKeys(cmds)
+	index := make(map[lineKey]int, len(keys))
+	for i, key := range keys {
+		index[key] = i
+	}
+
+	// Commands which will be written in place of their source lines.
+	sourced := make(map[lineKey]bool)
+	for _, line := range doc.Lines {
+		if line.key == nil {
+			continue
+		}
+		if _, ok := doc.parsed[*line.key]; ok {
+			sourced[*line.key] = true
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	ending := doc.lineEnding()
+	lastEnding := ending
+	writeLine := func(text string, end string) {
+		// Source's last line can be written without line ending.
+		if lastEnding == "" {
+			buf.WriteString(ending)
+		}
+		buf.WriteString(text)
+		buf.WriteString(end)
+		lastEnding = end
+	}
+
+	// Indentation of the source lines by level. Commands inserted
+	// are indented as the preceding source lines of the same level.
+	indents := make(map[int]string)
+	for _, line := range doc.Lines {
+		if line.key == nil || !sourced[*line.key] {
+			continue
+		}
+		if j, ok := index[*line.key]; ok {
+			if _, ok := indents[cmds[j].level]; !ok {
+				indents[cmds[j].level] = line.Indent
+			}
+		}
+	}
+
+	// Write new commands preceding the given one.
+	next := 0
+	flush := func(upto int) {
+		for ; next < upto; next++ {
+			if !sourced[keys[next]] {
+				writeLine(formatIndented(cmds[next], indents), ending)
+			}
+		}
+	}
+
+	for i := range doc.Lines {
+		line := &doc.Lines[i]
+
+		if line.key == nil {
+			writeLine(line.String(), line.Ending)
+			continue
+		}
+
+		parsed, ok := doc.parsed[*line.key]
+		if !ok {
+			// Line doesn't define any value, e.g. overridden one.
+			writeLine(line.String(), line.Ending)
+			continue
+		}
+
+		j, ok := index[*line.key]
+		if !ok {
+			// Command was removed from the sheet.
+			continue
+		}
+
+		flush(j)
+		indents[cmds[j].level] = line.Indent
+		if equalStrings(cmds[j].params, parsed) {
+			writeLine(line.String(), line.Ending)
+		} else {
+			writeLine(line.format(cmds[j]), line.Ending)
+		}
+	}
+	flush(len(cmds))
+
+	data, err := encodeText(buf.String(), doc.Charset, doc.bom)
+	if err != nil {
+		return 0, err
+	}
+	written, err := w.Write(data)
+
+	return int64(written), err
+}
+
+// formatIndented returns command text indented as the document lines
+// of the same level. If there are no such lines, indentation is derived
+// from the track lines one or the canonical one is used.
+func formatIndented(cmd command, indents map[int]string) string {
+	text := strings.TrimLeft(formatCommand(cmd), " ")
+	if indent, ok := indents[cmd.level]; ok {
+		return indent + text
+	}
+	if indent, ok := indents[1]; ok && indent != "" {
+		return strings.Repeat(indent, cmd.level) + text
+	}
+
+	return strings.Repeat("  ", cmd.level) + text
+}
+
+// format returns text of the line with new command parameters
+// written in the line's style.
+func (line *Line) format(cmd command) string {
+	buf := bytes.NewBufferString(line.Indent)
+	buf.WriteString(line.Command)
+
+	sep := line.Params[:len(line.Params)-len(strings.TrimLeftFunc(line.Params, unicode.IsSpace))]
+	if sep == "" {
+		sep = " "
+	}
+
+	for i, param := range cmd.params {
+		if i == 0 {
+			buf.WriteString(sep)
+		} else {
+			buf.WriteByte(' ')
+		}
+
+		if i < len(line.params) {
+			if q := line.params[i].quote; q != 0 {
+				buf.WriteString(quoteStringWith(param, q))
+			} else {
+				buf.WriteString(quoteStringIfNeeded(param))
+			}
+		} else if cmd.quoted[i] {
+			buf.WriteString(quoteString(param))
+		} else {
+			buf.WriteString(quoteStringIfNeeded(param))
+		}
+	}
+
+	return buf.String()
+}
+
+// lineEnding returns line ending used in the document.
+func (doc *Document) lineEnding() string {
+	for _, line := range doc.Lines {
+		if line.Ending != "" {
+			return line.Ending
+		}
+	}
+
+	return "\n"
+}
+
+// commandKeys returns keys identifying the given commands.
+func commandKeys(cmds []command) []lineKey {
+	keys := make([]lineKey, len(cmds))
+	counts := make(map[lineKey]int)
+
+	for i, cmd := range cmds {
+		key := lineKey{scope: cmd.scope, name: keyName(cmd.name, cmd.params)}
+		key.n = counts[key]
+		counts[key]++
+		keys[i] = key
+	}
+
+	return keys
+}
+
+// keyName returns command name used in the command key. REM metadata
+// commands are distinguished by their keys, so the order they are written
+// in doesn't matter.
+func keyName(cmd string, params []string) string {
+	if cmd == "REM" && isRemMetadata(params) {
+		return cmd + " " + params[0]
+	}
+
+	return cmd
+}
+
+// splitLines splits text into lines keeping all the whitespace characters.
+func splitLines(text string) []Line {
+	var lines []Line
+
+	for len(text) > 0 {
+		var line Line
+
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			i = len(text)
+		} else {
+			line.Ending = "\n"
+			if i > 0 && text[i-1] == '\r' {
+				line.Ending = "\r\n"
+				i--
+			}
+		}
+		str := text[:i]
+		text = text[i+len(line.Ending):]
+
+		body := strings.TrimLeftFunc(str, unicode.IsSpace)
+		line.Indent = str[:len(str)-len(body)]
+		if j := strings.IndexFunc(body, unicode.IsSpace); j < 0 {
+			line.Command = body
+		} else {
+			line.Command = body[:j]
+			line.Params = body[j:]
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+// equalStrings returns true if both slices have the same elements.
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
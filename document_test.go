@@ -0,0 +1,99 @@
+package cue
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDocumentUnchanged(t *testing.T) {
+	data, err := os.ReadFile("test.cue")
+	if err != nil {
+		t.Fatalf("Failed to read file. %s", err.Error())
+	}
+
+	doc, err := ParseDocument(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to parse file. %s", err.Error())
+	}
+
+	assertDocument(t, doc, string(data))
+}
+
+func TestDocumentEdit(t *testing.T) {
+	input := "REM COMMENT  first\r\n" +
+		"TITLE 'Album'\r\n" +
+		"\r\n" +
+		"FILE \"a.wav\" WAVE\r\n" +
+		"\tTRACK 1 AUDIO\r\n" +
+		"\t\tTITLE   'One'\r\n" +
+		"\t\tINDEX 01 00:00:00\r\n" +
+		"\tTRACK 2 AUDIO\r\n" +
+		"\t\tTITLE \"Two\"\r\n" +
+		"\t\tINDEX 01 03:00:00\r\n" +
+		"\tTRACK 3 AUDIO\r\n" +
+		"\t\tINDEX 01 05:00:00"
+
+	doc, err := ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document. %s", err.Error())
+	}
+
+	sheet := doc.Sheet
+	sheet.Title = "New Album"
+	sheet.Files[0].Tracks[0].Title = "It's One"
+	sheet.Files[0].Tracks[1].Isrc = "ABCDE1234567"
+	sheet.Files[0].Tracks[2].Title = "Three"
+
+	etalon := "REM COMMENT  first\r\n" +
+		"TITLE 'New Album'\r\n" +
+		"\r\n" +
+		"FILE \"a.wav\" WAVE\r\n" +
+		"\tTRACK 1 AUDIO\r\n" +
+		"\t\tTITLE   'It\\'s One'\r\n" +
+		"\t\tINDEX 01 00:00:00\r\n" +
+		"\tTRACK 2 AUDIO\r\n" +
+		"\t\tISRC ABCDE1234567\r\n" +
+		"\t\tTITLE \"Two\"\r\n" +
+		"\t\tINDEX 01 03:00:00\r\n" +
+		"\tTRACK 3 AUDIO\r\n" +
+		"\t\tTITLE \"Three\"\r\n" +
+		"\t\tINDEX 01 05:00:00"
+
+	assertDocument(t, doc, etalon)
+}
+
+func TestDocumentRemove(t *testing.T) {
+	input := "TITLE \"Album\"\n" +
+		"FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    TITLE \"Two\"\n" +
+		"    INDEX 01 03:00:00\n"
+
+	doc, err := ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse document. %s", err.Error())
+	}
+	doc.Sheet.Title = ""
+	doc.Sheet.Files[0].Tracks = doc.Sheet.Files[0].Tracks[:1]
+
+	etalon := "FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n"
+
+	assertDocument(t, doc, etalon)
+}
+
+func assertDocument(t *testing.T, doc *Document, etalon string) {
+	buf := new(bytes.Buffer)
+	if _, err := doc.WriteTo(buf); err != nil {
+		t.Fatalf("Failed to write document. %s", err.Error())
+	}
+
+	if buf.String() != etalon {
+		t.Fatalf("Written:\n%q\nbut expected:\n%q", buf.String(), etalon)
+	}
+}
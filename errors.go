@@ -0,0 +1,145 @@
+package cue
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kinds of parse errors. ParseError unwraps to one of them,
+// so they can be checked with errors.Is.
+var (
+	// Malformed command line: bad quotation or escape sequence.
+	ErrSyntax = errors.New("Syntax error")
+	// Command is not known.
+	ErrUnknownCommand = errors.New("Unknown command")
+	// Command has wrong number of parameters.
+	ErrParamCount = errors.New("Wrong number of parameters")
+	// Malformed or illegal mm:ss:ff time value.
+	ErrBadTime = errors.New("Bad time")
+	// Malformed or illegal parameter value.
+	ErrBadValue = errors.New("Bad value")
+	// Command appears in wrong place, e.g. INDEX before TRACK.
+	ErrOrder = errors.New("Command out of order")
+	// Track or index number breaks numbers sequence.
+	ErrSequence = errors.New("Wrong number sequence")
+	// Text is longer than 80 characters and is truncated.
+	ErrTooLong = errors.New("Text too long")
+)
+
+// ParseError describes a problem in the cue sheet source.
+type ParseError struct {
+	// Line number (starting from 1).
+	Line int
+	// Column number in characters (starting from 1).
+	Column int
+	// Command the error occured in.
+	Command string
+	// Error kind: ErrSyntax, ErrUnknownCommand, etc.
+	Kind error
+	// Detailed error description.
+	Err error
+}
+
+// Error implements error interface.
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("Line %d, column %d. %s", e.Line, e.Column, e.Err.Error())
+}
+
+// Unwrap returns error kind.
+func (e *ParseError) Unwrap() error {
+	return e.Kind
+}
+
+// ErrorList is a list of parse errors found in one cue sheet.
+type ErrorList []*ParseError
+
+// Error implements error interface.
+func (list ErrorList) Error() string {
+	if len(list) == 1 {
+		return list[0].Error()
+	}
+
+	msgs := make([]string, len(list))
+	for i, err := range list {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d errors found.\n%s", len(list), strings.Join(msgs, "\n"))
+}
+
+// Unwrap returns all the errors of the list.
+func (list ErrorList) Unwrap() []error {
+	errs := make([]error, len(list))
+	for i, err := range list {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// commandError is an error found while parsing a single command line.
+type commandError struct {
+	kind error
+	// Index of the wrong parameter or -1 if the whole command is wrong.
+	param int
+	// Byte offset of the error in the command line or -1 if unknown.
+	offset int
+	err    error
+}
+
+// Error implements error interface.
+func (e *commandError) Error() string {
+	return e.err.Error()
+}
+
+// newCommandError returns command error for the given parameter.
+// Use -1 param for errors of the whole command.
+func newCommandError(kind error, param int, format string, args ...interface{}) error {
+	return &commandError{kind, param, -1, fmt.Errorf(format, args...)}
+}
+
+// newSyntaxError returns syntax error found at the given offset.
+func newSyntaxError(offset int, format string, args ...interface{}) error {
+	return &commandError{ErrSyntax, -1, offset, fmt.Errorf(format, args...)}
+}
+
+// wrapCommandError returns command error of the same kind and position
+// as the given one but with a new description.
+func wrapCommandError(err error, format string, args ...interface{}) error {
+	ce, ok := err.(*commandError)
+	if !ok {
+		ce = &commandError{ErrBadValue, -1, -1, err}
+	}
+
+	return &commandError{ce.kind, ce.param, ce.offset, fmt.Errorf(format, args...)}
+}
+
+// newParseError returns ParseError for the command error found in the given line.
+func newParseError(line *Line, number int, cmd string, params []token, err error) *ParseError {
+	ce, ok := err.(*commandError)
+	if !ok {
+		ce = &commandError{ErrBadValue, -1, -1, err}
+	}
+
+	offset := 0
+	if ce.offset >= 0 {
+		offset = ce.offset
+	} else if ce.param >= 0 && ce.param < len(params) {
+		offset = params[ce.param].offset
+	}
+	text := line.Command + line.Params
+	if offset > len(text) {
+		offset = len(text)
+	}
+	column := utf8.RuneCountInString(line.Indent) + utf8.RuneCountInString(text[:offset]) + 1
+
+	return &ParseError{
+		Line:    number,
+		Column:  column,
+		Command: cmd,
+		Kind:    ce.kind,
+		Err:     ce.err,
+	}
+}
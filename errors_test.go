@@ -0,0 +1,92 @@
+package cue
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type parseErrorTest struct {
+	Input   string
+	Line    int
+	Column  int
+	Command string
+	Kind    error
+}
+
+func TestParseError(t *testing.T) {
+	var tests = []parseErrorTest{
+		{"TITLE \"Album\"\n\nFOO bar\n", 3, 1, "FOO", ErrUnknownCommand},
+		{"TITLE \"Album\" \"Other\"", 1, 1, "TITLE", ErrParamCount},
+		{"TITLE \"Al\\bum\"", 1, 10, "TITLE", ErrSyntax},
+		{"FILE \"a.wav\" WAVE\n\n  TRACK 01 AUDIO\n    INDEX 01 00:61:00\n", 4, 14, "INDEX", ErrBadTime},
+		{"FILE \"a.wav\" WAVE\n  TRACK 01 AUDIO\n    INDEX 01 00:01:00\n", 3, 14, "INDEX", ErrBadTime},
+		{"  INDEX 01 00:00:00\n", 1, 3, "INDEX", ErrOrder},
+		{"FILE \"a.wav\" WAVE\n  TRACK 01 AUDIO\n  TRACK 03 AUDIO\n", 3, 9, "TRACK", ErrSequence},
+		{"FILE \"a.wav\" WAV\n", 1, 14, "FILE", ErrBadValue},
+		{"CATALOG 123\n", 1, 9, "CATALOG", ErrBadValue},
+		{"FILE \"Привет.wav\" WAV\n", 1, 19, "FILE", ErrBadValue},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(strings.NewReader(tt.Input))
+		if err == nil {
+			t.Fatalf("Error expected for input %q", tt.Input)
+		}
+
+		var perr *ParseError
+		if !errors.As(err, &perr) {
+			t.Fatalf("ParseError expected but %T recieved", err)
+		}
+		if perr.Line != tt.Line || perr.Column != tt.Column {
+			t.Fatalf("Error at %d:%d but %d:%d expected. %s",
+				perr.Line, perr.Column, tt.Line, tt.Column, err.Error())
+		}
+		if perr.Command != tt.Command {
+			t.Fatalf("Error in %s command but %s expected", perr.Command, tt.Command)
+		}
+		if !errors.Is(err, tt.Kind) {
+			t.Fatalf("Error kind %v but %v expected", perr.Kind, tt.Kind)
+		}
+	}
+}
+
+func TestParseErrorList(t *testing.T) {
+	input := "TITLE \"Album\" \"Extra\"\n" +
+		"FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    ISRC 123\n" +
+		"    INDEX 01 03:00:80\n" +
+		"    INDEX 01 04:00:00\n"
+
+	doc, err := ParseWithOptions(strings.NewReader(input), ParseOptions{MaxErrors: -1, Strict: true})
+	var list ErrorList
+	if !errors.As(err, &list) {
+		t.Fatalf("ErrorList expected but %v recieved", err)
+	}
+
+	lines := []int{1, 6, 7}
+	if len(list) != len(lines) {
+		t.Fatalf("%d errors found but %d expected. %s", len(list), len(lines), err.Error())
+	}
+	for i, perr := range list {
+		if perr.Line != lines[i] {
+			t.Fatalf("Error at line %d but %d expected", perr.Line, lines[i])
+		}
+	}
+	if !errors.Is(err, ErrBadTime) {
+		t.Fatalf("ErrorList should contain ErrBadTime error")
+	}
+
+	tracks := doc.Sheet.Files[0].Tracks
+	if len(tracks) != 2 || len(tracks[1].Indexes) != 1 {
+		t.Fatalf("Partial sheet is not filled: %+v", doc.Sheet)
+	}
+
+	_, err = ParseWithOptions(strings.NewReader(input), ParseOptions{MaxErrors: 2, Strict: true})
+	if !errors.As(err, &list) || len(list) != 2 {
+		t.Fatalf("2 errors expected but %v recieved", err)
+	}
+}
@@ -0,0 +1,214 @@
+package cue
+
+import (
+	"fmt"
+)
+
+// FileRange is a part of the disc stored in one file.
+type FileRange struct {
+	// File name.
+	Name string
+	// File type.
+	Type FileType
+	// Disc positions of the [Start, End) range.
+	Start int
+	End   int
+}
+
+// Rebase returns copy of the sheet with the tracks placed into the files
+// storing the given disc ranges. toc is the sheet's TOC. Index positions are
+// rebased onto the new files, generated PREGAP and POSTGAP silence is not
+// counted as it is not stored in the files. Indexes out of the ranges are
+// dropped together with the tracks having no indexes left.
+func (sheet *CueSheet) Rebase(toc *TOC, files []FileRange) *CueSheet {
+	result := copySheet(sheet)
+	for _, r := range files {
+		result.Files = append(result.Files, File{Name: r.Name, Type: r.Type})
+	}
+
+	n := 0
+	for i := range sheet.Files {
+		for _, track := range sheet.Files[i].Tracks {
+			t := toc.Tracks[n]
+			n++
+
+			file := -1
+			var indexes []Index
+			for j, index := range t.Indexes {
+				f := rangeIndex(files, index.Offset)
+				if f < 0 {
+					continue
+				}
+				if file < 0 {
+					file = f
+				}
+				pos := toc.audioLength(files[f].Start, index.Offset)
+				indexes = append(indexes, Index{
+					Number:     track.Indexes[j].Number,
+					Time:       TimeFromFrames(pos),
+					FileOffset: f - file,
+				})
+			}
+			if file < 0 {
+				continue
+			}
+
+			track = copyTrack(track)
+			track.Indexes = indexes
+			result.Files[file].Tracks = append(result.Files[file].Tracks, track)
+		}
+	}
+
+	return result
+}
+
+// rangeIndex returns index of the range containing the disc position
+// or -1 if there is no such range.
+func rangeIndex(files []FileRange, lba int) int {
+	for i, r := range files {
+		if lba >= r.Start && lba < r.End {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// copySheet returns copy of the sheet without files.
+func copySheet(sheet *CueSheet) *CueSheet {
+	result := *sheet
+	result.Comments = append([]string(nil), sheet.Comments...)
+	result.Rem = copyRem(sheet.Rem)
+	result.Files = nil
+
+	return &result
+}
+
+// copyTrack returns copy of the track.
+func copyTrack(track Track) Track {
+	track.Flags = append([]TrackFlag(nil), track.Flags...)
+	track.Indexes = append([]Index(nil), track.Indexes...)
+	track.Comments = append([]string(nil), track.Comments...)
+	track.Rem = copyRem(track.Rem)
+
+	return track
+}
+
+// copyRem returns deep copy of the REM metadata.
+func copyRem(rem Rem) Rem {
+	rem.AlbumGain = copyReplayGain(rem.AlbumGain)
+	rem.TrackGain = copyReplayGain(rem.TrackGain)
+	if rem.Other != nil {
+		other := make(map[string][]string, len(rem.Other))
+		for key, values := range rem.Other {
+			other[key] = append([]string(nil), values...)
+		}
+		rem.Other = other
+	}
+
+	return rem
+}
+
+// copyReplayGain returns deep copy of the ReplayGain values.
+func copyReplayGain(rg *ReplayGain) *ReplayGain {
+	if rg == nil {
+		return nil
+	}
+	c := new(ReplayGain)
+	if rg.Gain != nil {
+		gain := *rg.Gain
+		c.Gain = &gain
+	}
+	if rg.Peak != nil {
+		peak := *rg.Peak
+		c.Peak = &peak
+	}
+
+	return c
+}
+
+// Layout defines how the disc audio is stored in the sheet files.
+type Layout int
+
+const (
+	// File per track, pregap of the track is stored at the end of the
+	// previous track file (EAC "noncompliant" sheet).
+	LayoutGapsAppended Layout = iota
+	// File per track, pregap of the track is stored at the beginning
+	// of its file (EAC "compliant" sheet).
+	LayoutGapsPrepended
+	// All the tracks are stored in one file.
+	LayoutSingleFile
+)
+
+// RelayoutOptions configures Relayout.
+type RelayoutOptions struct {
+	// Name returns name of the file storing the track with the given
+	// number. Single file is named with zero number.
+	Name func(number int) string
+}
+
+// Relayout returns copy of the sheet describing the same audio stored
+// with the given layout in the files named with opts.Name. lengths are
+// lengths of all the sheet files. Audio files are not touched. Single file
+// keeps the type of the first sheet file, track file keeps the type of the
+// file storing track INDEX 01.
+func Relayout(sheet *CueSheet, layout Layout, lengths []Time, opts RelayoutOptions) (*CueSheet, error) {
+	if opts.Name == nil {
+		return nil, fmt.Errorf("File names are not given.")
+	}
+	toc, err := sheet.TOC(lengths...)
+	if err != nil {
+		return nil, err
+	}
+	if len(toc.Tracks) == 0 {
+		return nil, fmt.Errorf("Sheet has no tracks.")
+	}
+
+	if layout == LayoutSingleFile {
+		r := FileRange{Name: opts.Name(0), Type: sheet.Files[0].Type, Start: 0, End: toc.Leadout}
+
+		return sheet.Rebase(toc, []FileRange{r}), nil
+	}
+
+	// Source file of every track.
+	var sources []int
+	for i, file := range sheet.Files {
+		for _, track := range file.Tracks {
+			f := i
+			for _, index := range track.Indexes {
+				if index.Number == 1 {
+					f = i + index.FileOffset
+				}
+			}
+			sources = append(sources, f)
+		}
+	}
+
+	var files []FileRange
+	tracks := toc.Tracks
+	for i, track := range tracks {
+		start, end := track.Offset, toc.Leadout
+		if layout == LayoutGapsPrepended {
+			start = track.Indexes[0].Offset
+		}
+		if i == 0 {
+			start = 0
+		}
+		if i+1 < len(tracks) {
+			end = tracks[i+1].Offset
+			if layout == LayoutGapsPrepended {
+				end = tracks[i+1].Indexes[0].Offset
+			}
+		}
+
+		files = append(files, FileRange{
+			Name:  opts.Name(track.Number),
+			Type:  sheet.Files[sources[i]].Type,
+			Start: start,
+			End:   end,
+		})
+	}
+
+	return sheet.Rebase(toc, files), nil
+}
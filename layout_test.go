@@ -0,0 +1,141 @@
+package cue
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRebase(t *testing.T) {
+	sheet, err := Parse(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	toc, err := sheet.TOC(Time{4, 2, 0}, Time{5, 0, 0})
+	if err != nil {
+		t.Fatalf("Failed to build TOC. %s", err.Error())
+	}
+
+	tests := []struct {
+		files  []FileRange
+		etalon string
+	}{
+		{[]FileRange{{"image.wav", FileTypeWave, 0, toc.Leadout}},
+			"FILE \"image.wav\" WAVE\n" +
+				"  TRACK 01 AUDIO\n" +
+				"    INDEX 01 00:00:00\n" +
+				"  TRACK 02 AUDIO\n" +
+				"    INDEX 00 04:00:00\n" +
+				"    INDEX 01 04:02:00\n" +
+				"  TRACK 03 AUDIO\n" +
+				"    INDEX 00 07:01:00\n" +
+				"    INDEX 01 07:02:00\n"},
+		// Track 1 and 3 are dropped, track 2 pregap is cut.
+		{[]FileRange{{"02.bin", FileTypeBinary, 18075, 31575}},
+			"FILE \"02.bin\" BINARY\n" +
+				"  TRACK 02 AUDIO\n" +
+				"    INDEX 01 00:01:00\n"},
+	}
+
+	for _, test := range tests {
+		buf := new(bytes.Buffer)
+		if err := Write(buf, sheet.Rebase(toc, test.files)); err != nil {
+			t.Fatalf("Failed to write. %s", err.Error())
+		}
+		if buf.String() != test.etalon {
+			t.Fatalf("Rebased to\n%s\nbut\n%s\nexpected", buf.String(), test.etalon)
+		}
+	}
+
+	// Source sheet is not modified.
+	if len(sheet.Files) != 2 || sheet.Files[0].Tracks[1].Indexes[0].Time != (Time{4, 0, 0}) {
+		t.Fatalf("Source sheet is modified")
+	}
+}
+
+func TestRelayout(t *testing.T) {
+	titles := []string{"Unholy Love", "I Had Too Much to Dream", "Rock On"}
+	sheet, err := Parse(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	// Files of the relayouted sheets are named as "01 - Unholy Love.flac"
+	// and "Doro - Doro.flac".
+	opts := RelayoutOptions{Name: func(number int) string {
+		if number == 0 {
+			return "Doro - Doro.flac"
+		}
+		return fmt.Sprintf("%02d - %s.flac", number, titles[number-1])
+	}}
+	if _, err := Relayout(sheet, LayoutSingleFile, []Time{{4, 2, 0}}, opts); err == nil {
+		t.Fatalf("Relayout without all the file lengths")
+	}
+	if _, err := Relayout(sheet, LayoutSingleFile, []Time{{4, 2, 0}, {5, 0, 0}}, RelayoutOptions{}); err == nil {
+		t.Fatalf("Relayout without file names")
+	}
+
+	prepended := "FILE \"01 - Unholy Love.flac\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"FILE \"02 - I Had Too Much to Dream.flac\" WAVE\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    INDEX 00 00:00:00\n" +
+		"    INDEX 01 00:02:00\n" +
+		"FILE \"03 - Rock On.flac\" WAVE\n" +
+		"  TRACK 03 AUDIO\n" +
+		"    INDEX 00 00:00:00\n" +
+		"    INDEX 01 00:01:00\n"
+	appended := "FILE \"01 - Unholy Love.flac\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    INDEX 00 04:00:00\n" +
+		"FILE \"02 - I Had Too Much to Dream.flac\" WAVE\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 03 AUDIO\n" +
+		"    INDEX 00 02:59:00\n" +
+		"FILE \"03 - Rock On.flac\" WAVE\n" +
+		"    INDEX 01 00:00:00\n"
+	single := "FILE \"Doro - Doro.flac\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    INDEX 00 04:00:00\n" +
+		"    INDEX 01 04:02:00\n" +
+		"  TRACK 03 AUDIO\n" +
+		"    INDEX 00 07:01:00\n" +
+		"    INDEX 01 07:02:00\n"
+
+	tests := []struct {
+		input   string
+		lengths []Time
+		layout  Layout
+		etalon  string
+	}{
+		{gapsAppended, []Time{{4, 2, 0}, {5, 0, 0}}, LayoutGapsPrepended, prepended},
+		{prepended, []Time{{4, 0, 0}, {3, 1, 0}, {2, 1, 0}}, LayoutGapsAppended, appended},
+		{appended, []Time{{4, 2, 0}, {3, 0, 0}, {2, 0, 0}}, LayoutSingleFile, single},
+		{single, []Time{{9, 2, 0}}, LayoutSingleFile, single},
+		{single, []Time{{9, 2, 0}}, LayoutGapsPrepended, prepended},
+		{appended, []Time{{4, 2, 0}, {3, 0, 0}, {2, 0, 0}}, LayoutGapsAppended, appended},
+	}
+
+	for _, test := range tests {
+		sheet, err := Parse(strings.NewReader(test.input))
+		if err != nil {
+			t.Fatalf("Failed to parse. %s", err.Error())
+		}
+		result, err := Relayout(sheet, test.layout, test.lengths, opts)
+		if err != nil {
+			t.Fatalf("Failed to relayout. %s", err.Error())
+		}
+		buf := new(bytes.Buffer)
+		if err := Write(buf, result); err != nil {
+			t.Fatalf("Failed to write. %s", err.Error())
+		}
+		if buf.String() != test.etalon {
+			t.Fatalf("Relayout to\n%s\nbut\n%s\nexpected", buf.String(), test.etalon)
+		}
+	}
+}
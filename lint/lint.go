@@ -0,0 +1,187 @@
+// Package lint checks cue sheets for style and correctness problems
+// which are not parse errors and fixes some of them.
+package lint
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// Severity of the problem.
+type Severity int
+
+const (
+	// Style issue.
+	SeverityInfo Severity = iota
+	// Possible mistake.
+	SeverityWarning
+	// Sheet is incorrect.
+	SeverityError
+)
+
+// String returns severity name.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// Rule is a single check.
+type Rule struct {
+	// Rule identifier, e.g. "missing-title".
+	ID string
+	// Severity of the problems found by the rule.
+	Severity Severity
+	// Rule is checked by default.
+	Enabled bool
+	// Description of the rule.
+	Description string
+	// Rule checks audio files and is skipped if Options.FS is nil.
+	files bool
+	check func(l *linter)
+}
+
+// Problem is a problem found in the sheet.
+type Problem struct {
+	// ID of the rule found the problem.
+	Rule string
+	// Severity of the problem.
+	Severity Severity
+	// Number of the track or zero if the problem is not bound to a track.
+	Track int
+	// Problem description.
+	Message string
+	// fix fixes the problem, nil if it can't be fixed automatically.
+	fix func()
+}
+
+// Fixable returns true if the problem can be fixed with Fix.
+func (p *Problem) Fixable() bool {
+	return p.fix != nil
+}
+
+// String returns problem description with its location and rule.
+func (p *Problem) String() string {
+	if p.Track > 0 {
+		return fmt.Sprintf("Track %d: %s (%s)", p.Track, p.Message, p.Rule)
+	}
+
+	return fmt.Sprintf("%s (%s)", p.Message, p.Rule)
+}
+
+// Options configures linting.
+type Options struct {
+	// Enables or disables rules by ID overriding Rule.Enabled.
+	Rules map[string]bool
+	// Filesystem with the sheet audio files and the sheet directory in it.
+	// Rules checking audio files are skipped if FS is nil.
+	FS  fs.FS
+	Dir string
+	// Parse warnings of the sheet, see cue.Document.Warnings.
+	// Rules reporting parser problems are checked with them.
+	Warnings []*cue.ParseError
+}
+
+// Lookup returns rule with the given ID or nil if there is no such rule.
+func Lookup(id string) *Rule {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Lint checks the sheet with all the enabled rules.
+func Lint(sheet *cue.CueSheet, opts Options) []Problem {
+	l := &linter{sheet: sheet, opts: opts}
+	for _, rule := range Rules {
+		enabled, ok := opts.Rules[rule.ID]
+		if !ok {
+			enabled = rule.Enabled
+		}
+		if !enabled || (rule.files && opts.FS == nil) {
+			continue
+		}
+
+		l.rule = rule
+		rule.check(l)
+	}
+
+	return l.problems
+}
+
+// Fix fixes problems found with Lint in place and returns the problems
+// fixed. Problems which can't be fixed automatically are left as is.
+func Fix(sheet *cue.CueSheet, opts Options) []Problem {
+	var fixed []Problem
+	for _, p := range Lint(sheet, opts) {
+		if p.fix != nil {
+			p.fix()
+			fixed = append(fixed, p)
+		}
+	}
+
+	return fixed
+}
+
+// linter keeps state of the sheet checking.
+type linter struct {
+	sheet    *cue.CueSheet
+	opts     Options
+	rule     *Rule
+	problems []Problem
+	// Resolved audio file paths, see files method.
+	paths    []string
+	resolved bool
+}
+
+// report adds problem found by the current rule. fix can be nil.
+func (l *linter) report(track int, fix func(), format string, args ...interface{}) {
+	l.problems = append(l.problems, Problem{
+		Rule:     l.rule.ID,
+		Severity: l.rule.Severity,
+		Track:    track,
+		Message:  fmt.Sprintf(format, args...),
+		fix:      fix,
+	})
+}
+
+// files returns paths of the sheet files in Options.FS. Path is empty
+// for the file which is not found.
+func (l *linter) files() []string {
+	if !l.resolved {
+		l.paths, _ = cue.Resolve(l.opts.FS, l.dir(), l.sheet)
+		l.resolved = true
+	}
+
+	return l.paths
+}
+
+// dir returns the sheet directory.
+func (l *linter) dir() string {
+	if l.opts.Dir == "" {
+		return "."
+	}
+
+	return l.opts.Dir
+}
+
+// tracks calls f for every sheet track.
+func (l *linter) tracks(f func(track *cue.Track)) {
+	for i := range l.sheet.Files {
+		for j := range l.sheet.Files[i].Tracks {
+			f(&l.sheet.Files[i].Tracks[j])
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package lint
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// newSheet returns valid sheet with two tracks in one BINARY file.
+func newSheet() *cue.CueSheet {
+	return &cue.CueSheet{
+		Title:     "Album",
+		Performer: "Band",
+		Catalog:   "0000000000000",
+		Files: []cue.File{{
+			Name: "a.bin",
+			Type: cue.FileTypeBinary,
+			Tracks: []cue.Track{
+				{Number: 1, Indexes: []cue.Index{{Number: 1}}},
+				{Number: 2, Indexes: []cue.Index{{Number: 1, Time: cue.Time{Sec: 10}}}},
+			},
+		}},
+	}
+}
+
+// problem is a problem without the fix function.
+type problem struct {
+	rule    string
+	track   int
+	fixable bool
+}
+
+func problems(list []Problem) []problem {
+	var result []problem
+	for _, p := range list {
+		result = append(result, problem{p.Rule, p.Track, p.Fixable()})
+	}
+
+	return result
+}
+
+func TestLint(t *testing.T) {
+	// 00:20:00 of raw audio.
+	fsys := fstest.MapFS{
+		"rip/a.bin":     {Data: make([]byte, 1500*2352)},
+		"rip/B.BIN":     {Data: make([]byte, 750*2352)},
+		"rip/CD1/c.bin": {Data: make([]byte, 2352)},
+		"rip/d.flac":    {Data: []byte("fLaC")},
+	}
+	long := strings.Repeat("a", 79)
+
+	tests := []struct {
+		modify   func(sheet *cue.CueSheet)
+		opts     Options
+		problems []problem
+	}{
+		{func(s *cue.CueSheet) {}, Options{}, nil},
+		{func(s *cue.CueSheet) { s.Title, s.Performer = "", "" }, Options{},
+			[]problem{{"missing-title", 0, false}, {"missing-performer", 0, false}}},
+		{func(s *cue.CueSheet) { s.Title = "" },
+			Options{Rules: map[string]bool{"missing-title": false}}, nil},
+		{func(s *cue.CueSheet) { s.Files[0].Tracks[1].Performer = "Band" }, Options{},
+			[]problem{{"redundant-performer", 2, true}}},
+		{func(s *cue.CueSheet) {
+			s.Title = long + "a"
+			s.Files[0].Tracks[0].Performer = strings.Repeat("Кино", 10)
+		}, Options{}, nil},
+		{func(s *cue.CueSheet) {
+			for i := range s.Files[0].Tracks {
+				s.Files[0].Tracks[i].Isrc = "ABCDE1234567"
+			}
+		}, Options{}, []problem{{"duplicate-isrc", 2, false}}},
+		{func(s *cue.CueSheet) { s.Catalog = "" }, Options{}, nil},
+		{func(s *cue.CueSheet) { s.Catalog = "" },
+			Options{Rules: map[string]bool{"missing-catalog": true}},
+			[]problem{{"missing-catalog", 0, false}}},
+		// Files are not checked without FS.
+		{func(s *cue.CueSheet) { s.Rem.DiscID = "FFFFFFFF"; s.Files[0].Name = "x.bin" }, Options{}, nil},
+		{func(s *cue.CueSheet) { s.Rem.DiscID = "05001402" }, Options{FS: fsys, Dir: "rip"}, nil},
+		{func(s *cue.CueSheet) { s.Rem.DiscID = "05001403" }, Options{FS: fsys, Dir: "rip"},
+			[]problem{{"discid-mismatch", 0, false}}},
+		{func(s *cue.CueSheet) { s.Rem.DiscID = "05001403"; s.Files[0].Name = "x.bin" },
+			Options{FS: fsys, Dir: "rip"}, []problem{{"missing-file", 0, false}}},
+		// Names are matched case-insensitively.
+		{func(s *cue.CueSheet) { s.Files[0].Name = "b.bin" }, Options{FS: fsys, Dir: "rip"}, nil},
+		{func(s *cue.CueSheet) { s.Files[0].Name = "d.wav" }, Options{FS: fsys, Dir: "rip"},
+			[]problem{{"missing-file", 0, true}}},
+		{func(s *cue.CueSheet) {
+			s.Files = append(s.Files, cue.File{Name: "CD1\\c.bin", Type: cue.FileTypeBinary})
+			s.Files[0].Name = "./a.bin"
+		}, Options{FS: fsys, Dir: "rip"},
+			[]problem{{"mixed-separators", 0, true}}},
+	}
+
+	for i, test := range tests {
+		sheet := newSheet()
+		test.modify(sheet)
+		p := problems(Lint(sheet, test.opts))
+		if !reflect.DeepEqual(p, test.problems) {
+			t.Fatalf("Test %d: problems %+v recieved but %+v expected", i, p, test.problems)
+		}
+	}
+}
+
+func TestTruncatedText(t *testing.T) {
+	input := "PERFORMER \"" + strings.Repeat("Кино", 20) + "\"\n" +
+		"TITLE \"" + strings.Repeat("a", 80) + "\"\n" +
+		"FILE \"a.bin\" BINARY\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    TITLE \"" + strings.Repeat("Группа крови ", 7) + "\"\n" +
+		"    INDEX 01 00:00:00\n"
+
+	doc, err := cue.ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	if p := Lint(doc.Sheet, Options{}); len(p) != 0 {
+		t.Fatalf("Problems %+v found without parse warnings", p)
+	}
+
+	list := Lint(doc.Sheet, Options{Warnings: doc.Warnings})
+	etalon := []problem{{"truncated-text", 0, false}}
+	if !reflect.DeepEqual(problems(list), etalon) {
+		t.Fatalf("Problems %+v recieved but %+v expected", problems(list), etalon)
+	}
+	if msg := list[0].Message; msg != "Line 5: TITLE is truncated at 80 characters." {
+		t.Fatalf("Unexpected message %s", msg)
+	}
+}
+
+func TestFix(t *testing.T) {
+	fsys := fstest.MapFS{"B.BIN": {Data: make([]byte, 1500*2352)}}
+
+	sheet := newSheet()
+	sheet.Rem.DiscID = "12345678"
+	sheet.Files[0].Name = "b.bin"
+	sheet.Files[0].Tracks[0].Performer = "Band"
+	sheet.Files[0].Tracks[1].Isrc = "ABCDE1234567"
+	sheet.Files[0].Tracks[0].Isrc = "ABCDE1234567"
+
+	opts := Options{FS: fsys}
+	fixed := problems(Fix(sheet, opts))
+	etalon := []problem{{"redundant-performer", 1, true}}
+	if !reflect.DeepEqual(fixed, etalon) {
+		t.Fatalf("Fixed %+v but %+v expected", fixed, etalon)
+	}
+	if sheet.Files[0].Tracks[0].Performer != "" {
+		t.Fatalf("Sheet is not fixed: %+v", sheet)
+	}
+
+	// Mismatching DISCID is reported but not overwritten.
+	left := problems(Lint(sheet, opts))
+	if !reflect.DeepEqual(left, []problem{{"duplicate-isrc", 2, false}, {"discid-mismatch", 0, false}}) {
+		t.Fatalf("Problems %+v left", left)
+	}
+	if sheet.Rem.DiscID != "12345678" {
+		t.Fatalf("DISCID is changed to %s", sheet.Rem.DiscID)
+	}
+
+	// File transcoded after ripping.
+	sheet = newSheet()
+	sheet.Files[0].Name = "c.wav"
+	fixed = problems(Fix(sheet, Options{FS: fstest.MapFS{"c.flac": {}}}))
+	if !reflect.DeepEqual(fixed, []problem{{"missing-file", 0, true}}) || sheet.Files[0].Name != "c.flac" {
+		t.Fatalf("Fixed %+v, file is %s", fixed, sheet.Files[0].Name)
+	}
+}
@@ -0,0 +1,213 @@
+package lint
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/vchimishuk/cue-go"
+)
+
+// Maximum length of CD-TEXT values, parser truncates longer ones.
+const maxTextLength = 80
+
+// Rules are all the known rules.
+var Rules = []*Rule{
+	{
+		ID:          "missing-title",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Description: "Disc has no TITLE.",
+		check:       checkMissingTitle,
+	},
+	{
+		ID:          "missing-performer",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Description: "Disc has no PERFORMER.",
+		check:       checkMissingPerformer,
+	},
+	{
+		ID:          "redundant-performer",
+		Severity:    SeverityInfo,
+		Enabled:     true,
+		Description: "Track PERFORMER equals disc PERFORMER.",
+		check:       checkRedundantPerformer,
+	},
+	{
+		ID:          "truncated-text",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Description: "TITLE, PERFORMER or SONGWRITER is truncated at 80 characters by the parser.",
+		check:       checkTruncatedText,
+	},
+	{
+		ID:          "duplicate-isrc",
+		Severity:    SeverityError,
+		Enabled:     true,
+		Description: "ISRC is used by several tracks.",
+		check:       checkDuplicateIsrc,
+	},
+	{
+		ID:          "missing-catalog",
+		Severity:    SeverityInfo,
+		Enabled:     false,
+		Description: "Disc has no CATALOG.",
+		check:       checkMissingCatalog,
+	},
+	{
+		ID:          "discid-mismatch",
+		Severity:    SeverityError,
+		Enabled:     true,
+		Description: "REM DISCID doesn't match the audio files.",
+		files:       true,
+		check:       checkDiscIDMismatch,
+	},
+	{
+		ID:          "missing-file",
+		Severity:    SeverityError,
+		Enabled:     true,
+		Description: "FILE is not found.",
+		files:       true,
+		check:       checkMissingFile,
+	},
+	{
+		ID:          "mixed-separators",
+		Severity:    SeverityWarning,
+		Enabled:     true,
+		Description: "FILE names use both slash and backslash path separators.",
+		check:       checkMixedSeparators,
+	},
+}
+
+func checkMissingTitle(l *linter) {
+	if l.sheet.Title == "" {
+		l.report(0, nil, "Disc has no TITLE.")
+	}
+}
+
+func checkMissingPerformer(l *linter) {
+	if l.sheet.Performer == "" {
+		l.report(0, nil, "Disc has no PERFORMER.")
+	}
+}
+
+func checkRedundantPerformer(l *linter) {
+	l.tracks(func(track *cue.Track) {
+		if track.Performer != "" && track.Performer == l.sheet.Performer {
+			l.report(track.Number, func() { track.Performer = "" },
+				"PERFORMER equals disc PERFORMER.")
+		}
+	})
+}
+
+func checkTruncatedText(l *linter) {
+	for _, w := range l.opts.Warnings {
+		if errors.Is(w, cue.ErrTooLong) {
+			l.report(0, nil, "Line %d: %s is truncated at %d characters.",
+				w.Line, w.Command, maxTextLength)
+		}
+	}
+}
+
+func checkDuplicateIsrc(l *linter) {
+	used := make(map[string]int)
+	l.tracks(func(track *cue.Track) {
+		if track.Isrc == "" {
+			return
+		}
+		if n, ok := used[track.Isrc]; ok {
+			l.report(track.Number, nil, "ISRC %s is already used by track %d.", track.Isrc, n)
+		} else {
+			used[track.Isrc] = track.Number
+		}
+	})
+}
+
+func checkMissingCatalog(l *linter) {
+	if l.sheet.Catalog == "" {
+		l.report(0, nil, "Disc has no CATALOG.")
+	}
+}
+
+func checkDiscIDMismatch(l *linter) {
+	if l.sheet.Rem.DiscID == "" {
+		return
+	}
+	expected, err := strconv.ParseUint(l.sheet.Rem.DiscID, 16, 32)
+	if err != nil {
+		l.report(0, nil, "REM DISCID %s is not a hexadecimal number.", l.sheet.Rem.DiscID)
+		return
+	}
+
+	// Missing files are reported by missing-file rule.
+	paths := l.files()
+	resolved := *l.sheet
+	resolved.Files = make([]cue.File, len(paths))
+	for i, p := range paths {
+		if p == "" {
+			return
+		}
+		resolved.Files[i] = cue.File{Name: p, Type: l.sheet.Files[i].Type}
+	}
+	lengths, err := cue.ProbeLengths(l.opts.FS, &resolved)
+	if err != nil {
+		l.report(0, nil, "REM DISCID can't be checked. %s", err.Error())
+		return
+	}
+	toc, err := l.sheet.TOC(lengths...)
+	if err != nil {
+		l.report(0, nil, "REM DISCID can't be checked. %s", err.Error())
+		return
+	}
+
+	// Mismatch means the files are not the rip the sheet describes,
+	// so it is not fixed automatically.
+	if id := toc.CDDBDiscID(); id != uint32(expected) {
+		l.report(0, nil, "REM DISCID %s doesn't match disc ID %08X.", l.sheet.Rem.DiscID, id)
+	}
+}
+
+func checkMissingFile(l *linter) {
+	paths := l.files()
+	// Files found without trying other extensions, names are normalized
+	// and matched case-insensitively as Resolve does.
+	found, _ := cue.ResolveWithOptions(l.opts.FS, l.dir(), l.sheet,
+		cue.ResolveOptions{Extensions: []string{}})
+
+	for i := range l.sheet.Files {
+		file := &l.sheet.Files[i]
+		if paths[i] == "" {
+			l.report(0, nil, "FILE %s is not found.", file.Name)
+			continue
+		}
+		if found[i] != "" {
+			continue
+		}
+
+		// File found with another extension, e.g. after transcoding.
+		name := paths[i]
+		if dir := l.dir(); dir != "." {
+			name = strings.TrimPrefix(name, dir+"/")
+		}
+		l.report(0, func() { file.Name = name }, "FILE %s is not found, %s exists.", file.Name, name)
+	}
+}
+
+func checkMixedSeparators(l *linter) {
+	var slash, backslash bool
+	for _, file := range l.sheet.Files {
+		slash = slash || strings.Contains(file.Name, "/")
+		backslash = backslash || strings.Contains(file.Name, "\\")
+	}
+	if !slash || !backslash {
+		return
+	}
+
+	l.report(0, func() {
+		for i := range l.sheet.Files {
+			file := &l.sheet.Files[i]
+			file.Name = strings.ReplaceAll(file.Name, "\\", "/")
+		}
+	}, "FILE names use both slash and backslash path separators.")
+}
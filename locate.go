@@ -0,0 +1,78 @@
+package cue
+
+import (
+	"fmt"
+)
+
+// Locate returns file and position in the file of the offset from the given
+// track start (INDEX 01). Negative offset points into the track pregap.
+// Error is returned for positions in PREGAP silence, which is not stored in
+// the files, and in the previous file, length of which is unknown.
+func (sheet *CueSheet) Locate(track int, offset Time) (*File, Time, error) {
+	for i := range sheet.Files {
+		for _, t := range sheet.Files[i].Tracks {
+			if t.Number != track {
+				continue
+			}
+
+			for _, index := range t.Indexes {
+				if index.Number != 1 {
+					continue
+				}
+
+				n := offset.TotalFrames()
+				if n < 0 {
+					pregap := t.Pregap.TotalFrames()
+					if -n <= pregap {
+						return nil, Time{}, fmt.Errorf("Position %s is in PREGAP silence.", offset)
+					}
+					n += pregap
+				}
+				pos := index.Time.TotalFrames() + n
+				if pos < 0 {
+					return nil, Time{}, fmt.Errorf("Position %s is in the previous file.", offset)
+				}
+
+				return &sheet.Files[i+index.FileOffset], TimeFromFrames(pos), nil
+			}
+
+			return nil, Time{}, fmt.Errorf("Track %d has no INDEX 01.", track)
+		}
+	}
+
+	return nil, Time{}, fmt.Errorf("There is no track %d.", track)
+}
+
+// TrackAt returns track and its index playing at the given position of the
+// file. Track can be started in one of the previous files. nil is returned
+// if the position is before the first index of the sheet or the file is not
+// one of the sheet files.
+func (sheet *CueSheet) TrackAt(file *File, time Time) (*Track, *Index) {
+	n := -1
+	for i := range sheet.Files {
+		if &sheet.Files[i] == file {
+			n = i
+		}
+	}
+	if n < 0 {
+		return nil, nil
+	}
+
+	var track *Track
+	var index *Index
+	for i := range sheet.Files {
+		for j := range sheet.Files[i].Tracks {
+			t := &sheet.Files[i].Tracks[j]
+			for k := range t.Indexes {
+				idx := &t.Indexes[k]
+				f := i + idx.FileOffset
+				if f > n || (f == n && idx.Time.Compare(time) > 0) {
+					return track, index
+				}
+				track, index = t, idx
+			}
+		}
+	}
+
+	return track, index
+}
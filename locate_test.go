@@ -0,0 +1,100 @@
+package cue
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocate(t *testing.T) {
+	input := gapsAppended +
+		"  TRACK 04 AUDIO\n" +
+		"    PREGAP 00:02:00\n" +
+		"    INDEX 01 04:00:00\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	tests := []struct {
+		track  int
+		offset Time
+		file   int
+		time   Time
+		ok     bool
+	}{
+		{1, Time{1, 0, 0}, 0, Time{1, 0, 0}, true},
+		{2, Time{0, 0, 0}, 1, Time{0, 0, 0}, true},
+		{2, Time{0, 30, 0}, 1, Time{0, 30, 0}, true},
+		// Pregap of track 2 is in the previous file.
+		{2, TimeFromFrames(-75), 0, Time{}, false},
+		{3, TimeFromFrames(-75), 1, Time{2, 59, 0}, true},
+		// PREGAP silence.
+		{4, TimeFromFrames(-75), 0, Time{}, false},
+		{4, TimeFromFrames(-225), 1, Time{3, 59, 0}, true},
+		{5, Time{}, 0, Time{}, false},
+	}
+
+	for _, test := range tests {
+		file, tm, err := sheet.Locate(test.track, test.offset)
+		if (err == nil) != test.ok {
+			t.Fatalf("Track %d offset %v located with error %v", test.track, test.offset, err)
+		}
+		if !test.ok {
+			continue
+		}
+		if file != &sheet.Files[test.file] || tm != test.time {
+			t.Fatalf("Track %d offset %v located at %s %v", test.track, test.offset, file.Name, tm)
+		}
+	}
+}
+
+func TestTrackAt(t *testing.T) {
+	input := "FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 00 00:00:00\n" +
+		"    INDEX 01 00:30:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    INDEX 00 03:00:00\n" +
+		"    INDEX 01 03:02:00\n" +
+		"FILE \"b.wav\" WAVE\n" +
+		"  TRACK 03 AUDIO\n" +
+		"    INDEX 01 00:00:00\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	appended, err := Parse(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	tests := []struct {
+		sheet *CueSheet
+		file  int
+		time  Time
+		track int
+		index int
+	}{
+		{sheet, 0, Time{0, 0, 0}, 1, 0},
+		{sheet, 0, Time{0, 30, 0}, 1, 1},
+		{sheet, 0, Time{3, 1, 0}, 2, 0},
+		{sheet, 0, Time{9, 0, 0}, 2, 1},
+		{sheet, 1, Time{0, 10, 0}, 3, 1},
+		{appended, 0, Time{4, 1, 0}, 2, 0},
+		{appended, 1, Time{0, 1, 0}, 2, 1},
+		{appended, 1, Time{2, 59, 10}, 3, 0},
+	}
+
+	for _, test := range tests {
+		track, index := test.sheet.TrackAt(&test.sheet.Files[test.file], test.time)
+		if track == nil || track.Number != test.track || index.Number != test.index {
+			t.Fatalf("Found %+v %+v at %v of file %d", track, index, test.time, test.file)
+		}
+	}
+
+	if track, _ := sheet.TrackAt(&File{}, Time{}); track != nil {
+		t.Fatalf("Found track in the unknown file")
+	}
+}
@@ -9,13 +9,54 @@ import (
 	"unicode"
 )
 
+// token is a command parameter together with its source representation details.
+type token struct {
+	// Parameter value.
+	value string
+	// Quotation character the parameter was wrapped with or 0 if it was not quoted.
+	quote byte
+	// Byte offset of the parameter in the command line.
+	offset int
+}
+
+// tokenValues returns values of the given tokens.
+func tokenValues(tokens []token) []string {
+	values := make([]string, len(tokens))
+	for i, t := range tokens {
+		values[i] = t.value
+	}
+
+	return values
+}
+
+// joinTokens joins the first n tokens into one space separated token.
+func joinTokens(tokens []token, n int) []token {
+	values := tokenValues(tokens[:n])
+	joined := token{value: strings.Join(values, " "), offset: tokens[0].offset}
+
+	return append([]token{joined}, tokens[n:]...)
+}
+
 // parseCommand retrive string line and parses it with the following algorythm:
 // * first word in the line is command name (cmd return value)
 // * all rest words are command's parameters
 // * if parameter includes more than one word it should be wrapped with ' or "
 func parseCommand(line string) (cmd string, params []string, err error) {
+	cmd, tokens, err := lexCommand(line)
+	if err != nil {
+		return
+	}
+	params = tokenValues(tokens)
+
+	return
+}
+
+// lexCommand splits line into command and parameters the same way
+// parseCommand does, but keeps quotation information for every parameter.
+// Offsets are counted from the first non-space character of the line.
+func lexCommand(line string) (cmd string, params []token, err error) {
 	line = strings.TrimSpace(line)
-	params = make([]string, 0)
+	params = make([]token, 0)
 
 	// Find cmd.
 	i := strings.IndexFunc(line, unicode.IsSpace)
@@ -24,11 +65,15 @@ func parseCommand(line string) (cmd string, params []string, err error) {
 		return
 	}
 	cmd = line[:i]
-	line = strings.TrimSpace(line[i:])
+	rest := strings.TrimSpace(line[i:])
+	base := strings.Index(line[i:], rest) + i
+	line = rest
 
 	// Split parameters.
 	l := len(line)
 	var quotedChar byte = 0
+	var quote byte = 0
+	start := 0
 	param := bytes.NewBufferString("")
 	for i = 0; i < l; i++ {
 		c := line[i]
@@ -38,27 +83,31 @@ func parseCommand(line string) (cmd string, params []string, err error) {
 				// Quote can be started only at the beginnig of the parameter,
 				// but not in the middle.
 				if param.Len() != 0 {
-					err = errors.New("Unexpected quortation character.")
+					err = newSyntaxError(base+i, "Unexpected quortation character.")
 					return
 				}
 				quotedChar = c
+				quote = c
+				start = i
 			} else if unicode.IsSpace(rune(c)) {
 				// In not quote mode space starts new parameter.
 				// But don't save empty parameters.
 				if param.Len() != 0 {
-					params = append(params, param.String())
+					params = append(params, token{param.String(), quote, base + start})
 					param = bytes.NewBufferString("")
 				}
+				quote = 0
+				start = i + 1
 			} else {
 				if c == '\\' { // Escape sequence in the text.
 					if i+1 >= l {
-						err = fmt.Errorf("Unfinished escape sequence")
+						err = newSyntaxError(base+i, "Unfinished escape sequence")
 						return
 					}
 
 					s, e := parseEscapeSequence(line[i : i+2])
 					if e != nil {
-						err = e
+						err = newSyntaxError(base+i, "%s", e.Error())
 						return
 					}
 					param.WriteByte(s)
@@ -73,13 +122,13 @@ func parseCommand(line string) (cmd string, params []string, err error) {
 			} else {
 				if c == '\\' { // Escape sequence in the text.
 					if i+1 >= l {
-						err = fmt.Errorf("Unfinished escape sequence")
+						err = newSyntaxError(base+i, "Unfinished escape sequence")
 						return
 					}
 
 					s, e := parseEscapeSequence(line[i : i+2])
 					if e != nil {
-						err = e
+						err = newSyntaxError(base+i, "%s", e.Error())
 						return
 					}
 					param.WriteByte(s)
@@ -91,7 +140,7 @@ func parseCommand(line string) (cmd string, params []string, err error) {
 		}
 	}
 
-	params = append(params, param.String())
+	params = append(params, token{param.String(), quote, base + start})
 
 	return
 }
@@ -134,14 +183,18 @@ func parseTime(length string) (min int, sec int, frames int, err error) {
 		err = errors.New("Failed to parse minutes. " + err.Error())
 		return
 	}
+	if min < 0 {
+		err = errors.New("Failed to parse minutes. Minutes value can't be negative.")
+		return
+	}
 
 	sec, err = strconv.Atoi(parts[1])
 	if err != nil {
 		err = errors.New("Failed to parse seconds. " + err.Error())
 		return
 	}
-	if sec > 59 {
-		err = errors.New("Failed to parse seconds. Seconds value can't be more than 59.")
+	if sec < 0 || sec > 59 {
+		err = errors.New("Failed to parse seconds. Seconds value should be in 0..59 range.")
 		return
 	}
 
@@ -150,10 +203,58 @@ func parseTime(length string) (min int, sec int, frames int, err error) {
 		err = errors.New("Failed to parse frames value. " + err.Error())
 		return
 	}
-	if frames > 74 {
-		err = errors.New("Failed to parse frames. Frames value can't be more than 74.")
+	if frames < 0 || frames > 74 {
+		err = errors.New("Failed to parse frames. Frames value should be in 0..74 range.")
 		return
 	}
 
 	return
 }
+
+// formatTime returns time string in mm:ss:ff format.
+func formatTime(time Time) string {
+	return fmt.Sprintf("%02d:%02d:%02d", time.Min, time.Sec, time.Frames)
+}
+
+// quoteString returns double quoted string with all special characters escaped,
+// so parseCommand parses it back as a single parameter.
+func quoteString(str string) string {
+	return quoteStringWith(str, '"')
+}
+
+// quoteStringWith returns string wrapped with the given quotation character.
+func quoteStringWith(str string, quote byte) string {
+	buf := bytes.NewBuffer([]byte{quote})
+
+	for i := 0; i < len(str); i++ {
+		switch c := str[i]; c {
+		case quote, '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case '\n':
+			buf.WriteString("\\n")
+		case '\t':
+			buf.WriteString("\\t")
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	buf.WriteByte(quote)
+
+	return buf.String()
+}
+
+// quoteStringIfNeeded returns string as is if it can be parsed back
+// as a single parameter, otherwise quoted string is returned.
+func quoteStringIfNeeded(str string) string {
+	if str == "" || strings.IndexFunc(str, needsQuote) >= 0 {
+		return quoteString(str)
+	}
+
+	return str
+}
+
+// needsQuote returns true if string with the given character should be quoted.
+func needsQuote(c rune) bool {
+	return unicode.IsSpace(c) || c == '\\' || c == '"' || c == '\''
+}
@@ -0,0 +1,539 @@
+package cue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+)
+
+// AudioInfo describes audio data of the file.
+type AudioInfo struct {
+	// Sample rate in Hz.
+	SampleRate int
+	// Number of channels.
+	Channels int
+	// Bits per sample.
+	BitDepth int
+	// Number of samples per channel.
+	Samples int64
+	// Length in CD frames rounded down.
+	Length Time
+	// Detected file type. Raw files have the type given to Probe.
+	Type FileType
+	// Samples are IEEE floating point numbers.
+	Float bool
+	// Offset of the audio data in WAVE and raw files.
+	DataOffset int64
+}
+
+// WAVE format tags.
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// wavSubFormatGUID is the WAVE_FORMAT_EXTENSIBLE sub-format GUID
+// following the format tag: XXXXXXXX-0000-0010-8000-00AA00389B71.
+var wavSubFormatGUID = [14]byte{0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00,
+	0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+// Probe reads audio file header. RIFF WAVE (including WAVE_FORMAT_EXTENSIBLE
+// and RF64), AIFF/AIFF-C, FLAC, Monkey's Audio and WavPack files are
+// recognised by their content regardless of the declared type, other files
+// are read as raw CD audio of the given type: little endian for
+// FileTypeBinary and big endian for FileTypeMotorola.
+func Probe(r io.ReadSeeker, fileType FileType) (*AudioInfo, error) {
+	var magic [12]byte
+	n, err := io.ReadFull(r, magic[:])
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+
+	// FLAC and APE files can be prefixed with ID3v2 tag.
+	if n == 12 && string(magic[:3]) == "ID3" {
+		size := int64(magic[6])<<21 | int64(magic[7])<<14 | int64(magic[8])<<7 | int64(magic[9])
+		if _, err := r.Seek(10+size, io.SeekStart); err != nil {
+			return nil, err
+		}
+		n, err = io.ReadFull(r, magic[:])
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	var info *AudioInfo
+	switch {
+	case n == 12 && (string(magic[:4]) == "RIFF" || string(magic[:4]) == "RF64") &&
+		string(magic[8:]) == "WAVE":
+		info, err = probeWave(r, string(magic[:4]) == "RF64")
+		fileType = FileTypeWave
+	case n == 12 && string(magic[:4]) == "FORM" &&
+		(string(magic[8:]) == "AIFF" || string(magic[8:]) == "AIFC"):
+		info, err = probeAiff(r)
+		fileType = FileTypeAiff
+	case n >= 4 && string(magic[:4]) == "fLaC":
+		info, err = probeFlac(r, magic[4:n])
+		fileType = FileTypeFlac
+	case n >= 6 && string(magic[:4]) == "MAC ":
+		info, err = probeApe(r, magic[:n])
+		fileType = FileTypeApe
+	case n >= 4 && string(magic[:4]) == "wvpk":
+		info, err = probeWavPack(r, magic[:n])
+		fileType = FileTypeWavPack
+	case fileType == FileTypeBinary || fileType == FileTypeMotorola:
+		info, err = probeRaw(r)
+	default:
+		return nil, fmt.Errorf("Unsupported audio file format.")
+	}
+	if err != nil {
+		return nil, err
+	}
+	info.Type = fileType
+
+	if info.SampleRate <= 0 || info.Channels <= 0 {
+		return nil, fmt.Errorf("Invalid audio format.")
+	}
+	info.Length = TimeFromFrames(int(info.Samples * FramesPerSecond / int64(info.SampleRate)))
+
+	return info, nil
+}
+
+// ProbeLengths returns lengths of all the sheet files, which can be passed
+// to TrackSpans and TOC. File names are relative to the fsys root, so
+// os.DirFS of the sheet directory can be used. Files should implement
+// io.Seeker.
+func ProbeLengths(fsys fs.FS, sheet *CueSheet) ([]Time, error) {
+	lengths := make([]Time, len(sheet.Files))
+
+	for i, file := range sheet.Files {
+		info, err := probeFile(fsys, file.Name, file.Type)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to probe %s. %s", file.Name, err.Error())
+		}
+		lengths[i] = info.Length
+	}
+
+	return lengths, nil
+}
+
+// probeFile returns audio info of the named file.
+func probeFile(fsys fs.FS, name string, fileType FileType) (*AudioInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		return nil, fmt.Errorf("File doesn't support seeking.")
+	}
+
+	return Probe(rs, fileType)
+}
+
+// chunkHeader is RIFF or IFF chunk header.
+type chunkHeader struct {
+	ID   [4]byte
+	Size uint32
+}
+
+// wavFormat is the beginning of WAVE fmt chunk.
+type wavFormat struct {
+	Format        uint16
+	Channels      uint16
+	SampleRate    uint32
+	ByteRate      uint32
+	BlockAlign    uint16
+	BitsPerSample uint16
+}
+
+// probeWave reads RIFF WAVE chunks following the file header.
+func probeWave(r io.ReadSeeker, rf64 bool) (*AudioInfo, error) {
+	info := new(AudioInfo)
+	var blockAlign int
+	var dataSize int64 = -1
+	var ds64Size int64 = -1
+	fmtFound := false
+
+chunks:
+	for !fmtFound || dataSize < 0 {
+		var h chunkHeader
+		if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+			return nil, fmt.Errorf("Failed to read WAVE chunk. %s", err.Error())
+		}
+		size := int64(h.Size)
+
+		switch string(h.ID[:]) {
+		case "ds64":
+			var ds64 struct {
+				RiffSize uint64
+				DataSize uint64
+			}
+			if err := readChunk(r, binary.LittleEndian, &ds64, size); err != nil {
+				return nil, err
+			}
+			ds64Size = int64(ds64.DataSize)
+			continue
+		case "fmt ":
+			var f wavFormat
+			var ext struct {
+				Format      wavFormat
+				Size        uint16
+				ValidBits   uint16
+				ChannelMask uint32
+				SubFormat   [16]byte
+			}
+			if size >= int64(binary.Size(&ext)) {
+				if err := readChunk(r, binary.LittleEndian, &ext, size); err != nil {
+					return nil, err
+				}
+				f = ext.Format
+			} else if err := readChunk(r, binary.LittleEndian, &f, size); err != nil {
+				return nil, err
+			}
+
+			format := f.Format
+			if format == wavFormatExtensible {
+				if size < int64(binary.Size(&ext)) {
+					return nil, fmt.Errorf("WAVE_FORMAT_EXTENSIBLE has no sub-format.")
+				}
+				if [14]byte(ext.SubFormat[2:]) != wavSubFormatGUID {
+					return nil, fmt.Errorf("Unsupported WAVE sub-format %X.", ext.SubFormat)
+				}
+				// Sub-format GUID starts with the format tag.
+				format = binary.LittleEndian.Uint16(ext.SubFormat[:])
+			}
+			if format != wavFormatPCM && format != wavFormatFloat {
+				return nil, fmt.Errorf("Unsupported WAVE format 0x%04X.", format)
+			}
+			info.Float = format == wavFormatFloat
+			info.SampleRate = int(f.SampleRate)
+			info.Channels = int(f.Channels)
+			info.BitDepth = int(f.BitsPerSample)
+			blockAlign = int(f.BlockAlign)
+			fmtFound = true
+			continue
+		case "data":
+			dataSize = size
+			if rf64 && h.Size == math.MaxUint32 {
+				dataSize = ds64Size
+			}
+			if dataSize < 0 {
+				return nil, fmt.Errorf("RF64 file has no ds64 chunk.")
+			}
+			offset, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			info.DataOffset = offset
+			// Audio data itself is not needed.
+			if fmtFound {
+				break chunks
+			}
+		}
+
+		if err := skipChunk(r, size); err != nil {
+			return nil, err
+		}
+	}
+
+	if blockAlign == 0 {
+		return nil, fmt.Errorf("Invalid WAVE block align.")
+	}
+	info.Samples = dataSize / int64(blockAlign)
+
+	return info, nil
+}
+
+// probeAiff reads AIFF or AIFF-C chunks following the file header.
+func probeAiff(r io.ReadSeeker) (*AudioInfo, error) {
+	for {
+		var h chunkHeader
+		if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+			return nil, fmt.Errorf("Failed to read AIFF chunk. %s", err.Error())
+		}
+		size := int64(h.Size)
+
+		if string(h.ID[:]) == "COMM" {
+			var comm struct {
+				Channels   uint16
+				Frames     uint32
+				SampleSize uint16
+				SampleRate [10]byte
+			}
+			if err := readChunk(r, binary.BigEndian, &comm, size); err != nil {
+				return nil, err
+			}
+
+			return &AudioInfo{
+				SampleRate: int(extendedFloat(comm.SampleRate)),
+				Channels:   int(comm.Channels),
+				BitDepth:   int(comm.SampleSize),
+				Samples:    int64(comm.Frames),
+			}, nil
+		}
+
+		if err := skipChunk(r, size); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// probeFlac reads FLAC STREAMINFO block. head is already read data
+// following the "fLaC" marker.
+func probeFlac(r io.Reader, head []byte) (*AudioInfo, error) {
+	// Metadata block header and STREAMINFO block.
+	buf := make([]byte, 4+34)
+	n := copy(buf, head)
+	if _, err := io.ReadFull(r, buf[n:]); err != nil {
+		return nil, fmt.Errorf("Failed to read FLAC STREAMINFO. %s", err.Error())
+	}
+	if buf[0]&0x7F != 0 {
+		return nil, fmt.Errorf("FLAC STREAMINFO block expected.")
+	}
+
+	// Sample rate (20 bits), channels - 1 (3 bits), bits per sample - 1
+	// (5 bits) and total samples (36 bits).
+	v := binary.BigEndian.Uint64(buf[4+10:])
+
+	return &AudioInfo{
+		SampleRate: int(v >> 44),
+		Channels:   int(v>>41&0x7) + 1,
+		BitDepth:   int(v>>36&0x1F) + 1,
+		Samples:    int64(v & 0xFFFFFFFFF),
+	}, nil
+}
+
+// probeApe reads Monkey's Audio header. head is already read data
+// starting with the "MAC " marker.
+func probeApe(r io.ReadSeeker, head []byte) (*AudioInfo, error) {
+	if _, err := r.Seek(-int64(len(head)), io.SeekCurrent); err != nil {
+		return nil, err
+	}
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+
+	var h struct {
+		Magic   [4]byte
+		Version uint16
+	}
+	if err := binary.Read(r, binary.LittleEndian, &h); err != nil {
+		return nil, fmt.Errorf("Failed to read APE header. %s", err.Error())
+	}
+
+	var channels, bits, rate, blocksPerFrame, finalBlocks, frames uint32
+	if h.Version >= 3980 {
+		var desc struct {
+			Padding         uint16
+			DescriptorBytes uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &desc); err != nil {
+			return nil, fmt.Errorf("Failed to read APE descriptor. %s", err.Error())
+		}
+		if _, err := r.Seek(start+int64(desc.DescriptorBytes), io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		var header struct {
+			CompressionLevel uint16
+			FormatFlags      uint16
+			BlocksPerFrame   uint32
+			FinalFrameBlocks uint32
+			TotalFrames      uint32
+			BitsPerSample    uint16
+			Channels         uint16
+			SampleRate       uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+			return nil, fmt.Errorf("Failed to read APE header. %s", err.Error())
+		}
+		channels = uint32(header.Channels)
+		bits = uint32(header.BitsPerSample)
+		rate = header.SampleRate
+		blocksPerFrame = header.BlocksPerFrame
+		finalBlocks = header.FinalFrameBlocks
+		frames = header.TotalFrames
+	} else {
+		var header struct {
+			CompressionLevel uint16
+			FormatFlags      uint16
+			Channels         uint16
+			SampleRate       uint32
+			HeaderBytes      uint32
+			TerminatingBytes uint32
+			TotalFrames      uint32
+			FinalFrameBlocks uint32
+		}
+		if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+			return nil, fmt.Errorf("Failed to read APE header. %s", err.Error())
+		}
+		channels = uint32(header.Channels)
+		rate = header.SampleRate
+		finalBlocks = header.FinalFrameBlocks
+		frames = header.TotalFrames
+
+		switch {
+		case header.FormatFlags&0x1 != 0:
+			bits = 8
+		case header.FormatFlags&0x8 != 0:
+			bits = 24
+		default:
+			bits = 16
+		}
+		switch {
+		case h.Version >= 3950:
+			blocksPerFrame = 73728 * 4
+		case h.Version >= 3900 || (h.Version >= 3800 && header.CompressionLevel == 4000):
+			blocksPerFrame = 73728
+		default:
+			blocksPerFrame = 9216
+		}
+	}
+
+	info := &AudioInfo{SampleRate: int(rate), Channels: int(channels), BitDepth: int(bits)}
+	if frames > 0 {
+		info.Samples = int64(frames-1)*int64(blocksPerFrame) + int64(finalBlocks)
+	}
+
+	return info, nil
+}
+
+// WavPack standard sample rates indexed by block header flags.
+var wavPackRates = []int{6000, 8000, 9600, 11025, 12000, 16000, 22050,
+	24000, 32000, 44100, 48000, 64000, 88200, 96000, 192000}
+
+// WavPack metadata sub-block IDs.
+const (
+	wavPackIDChannelInfo = 0x0D
+	wavPackIDSampleRate  = 0x27
+)
+
+// probeWavPack reads the first WavPack block. head is already read data
+// starting with the "wvpk" marker.
+func probeWavPack(r io.Reader, head []byte) (*AudioInfo, error) {
+	buf := make([]byte, 32)
+	n := copy(buf, head)
+	if _, err := io.ReadFull(r, buf[n:]); err != nil {
+		return nil, fmt.Errorf("Failed to read WavPack header. %s", err.Error())
+	}
+
+	size := int(binary.LittleEndian.Uint32(buf[4:])) + 8
+	total := binary.LittleEndian.Uint32(buf[12:])
+	flags := binary.LittleEndian.Uint32(buf[24:])
+	if total == math.MaxUint32 {
+		return nil, fmt.Errorf("WavPack file length is unknown.")
+	}
+
+	info := &AudioInfo{
+		Channels: 2,
+		BitDepth: int(flags&0x3+1) * 8,
+		Samples:  int64(buf[11])<<32 | int64(total),
+	}
+	if flags&0x4 != 0 {
+		info.Channels = 1
+	}
+	if i := int(flags >> 23 & 0xF); i < len(wavPackRates) {
+		info.SampleRate = wavPackRates[i]
+	}
+
+	// Non-standard sample rate and channels number are stored in metadata.
+	if size < 32 {
+		return nil, fmt.Errorf("Invalid WavPack block size.")
+	}
+	data := make([]byte, size-32)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("Failed to read WavPack block. %s", err.Error())
+	}
+	for len(data) >= 2 {
+		id := data[0]
+		length := int(data[1]) * 2
+		hdr := 2
+		if id&0x80 != 0 {
+			if len(data) < 4 {
+				break
+			}
+			length = (int(data[1]) | int(data[2])<<8 | int(data[3])<<16) * 2
+			hdr = 4
+		}
+		if len(data) < hdr+length {
+			break
+		}
+		value := data[hdr : hdr+length]
+		if id&0x40 != 0 && length > 0 {
+			value = value[:length-1]
+		}
+
+		switch id & 0x3F {
+		case wavPackIDChannelInfo:
+			if len(value) >= 1 {
+				info.Channels = int(value[0])
+			}
+		case wavPackIDSampleRate:
+			if len(value) >= 3 {
+				info.SampleRate = int(value[0]) | int(value[1])<<8 | int(value[2])<<16
+			}
+		}
+		data = data[hdr+length:]
+	}
+
+	return info, nil
+}
+
+// probeRaw returns info of the raw CD audio: 16-bit stereo PCM at 44100 Hz.
+func probeRaw(r io.ReadSeeker) (*AudioInfo, error) {
+	size, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AudioInfo{SampleRate: 44100, Channels: 2, BitDepth: 16, Samples: size / 4}, nil
+}
+
+// readChunk reads the beginning of the chunk into data
+// and skips the rest of the chunk.
+func readChunk(r io.ReadSeeker, order binary.ByteOrder, data interface{}, size int64) error {
+	n := int64(binary.Size(data))
+	if size < n {
+		return fmt.Errorf("Chunk is too short.")
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return fmt.Errorf("Failed to read chunk. %s", err.Error())
+	}
+	if err := binary.Read(bytes.NewReader(buf), order, data); err != nil {
+		return err
+	}
+
+	return skipChunk(r, size-n)
+}
+
+// skipChunk skips the rest of the chunk. Chunks are padded to even size.
+func skipChunk(r io.ReadSeeker, size int64) error {
+	if _, err := r.Seek(size+size%2, io.SeekCurrent); err != nil {
+		return fmt.Errorf("Failed to skip chunk. %s", err.Error())
+	}
+
+	return nil
+}
+
+// extendedFloat converts 80-bit IEEE 754 extended precision number to float64.
+func extendedFloat(b [10]byte) float64 {
+	exp := int(binary.BigEndian.Uint16(b[:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:])
+	if exp == 0 && mantissa == 0 {
+		return 0
+	}
+	f := math.Ldexp(float64(mantissa), exp-16383-63)
+	if b[0]&0x80 != 0 {
+		f = -f
+	}
+
+	return f
+}
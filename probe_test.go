@@ -0,0 +1,236 @@
+package cue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// chunk returns RIFF or IFF chunk with the given fields as the chunk data.
+func chunk(order binary.ByteOrder, id string, size int, fields ...interface{}) []byte {
+	buf := bytes.NewBufferString(id)
+	binary.Write(buf, order, uint32(size))
+	for _, f := range fields {
+		binary.Write(buf, order, f)
+	}
+
+	return buf.Bytes()
+}
+
+// wave returns WAVE file header with data chunk of the given size.
+// Audio data itself is not included. WAVE_FORMAT_EXTENSIBLE file has PCM sub-format.
+func wave(format uint16, channels int, rate int, bits int, dataSize int) []byte {
+	var guid [16]byte
+	guid[0] = wavFormatPCM
+	copy(guid[2:], wavSubFormatGUID[:])
+
+	return waveGUID(format, guid, channels, rate, bits, dataSize)
+}
+
+// waveGUID is like wave but uses the given WAVE_FORMAT_EXTENSIBLE sub-format.
+func waveGUID(format uint16, guid [16]byte, channels int, rate int, bits int, dataSize int) []byte {
+	align := uint16(channels * bits / 8)
+	buf := bytes.NewBufferString("RIFF\x00\x00\x00\x00WAVE")
+	buf.Write(chunk(binary.LittleEndian, "LIST", 3, [4]byte{}))
+	if format == wavFormatExtensible {
+		buf.Write(chunk(binary.LittleEndian, "fmt ", 40, format, uint16(channels),
+			uint32(rate), uint32(rate)*uint32(align), align, uint16(bits),
+			uint16(22), uint16(bits), uint32(3), guid))
+	} else {
+		buf.Write(chunk(binary.LittleEndian, "fmt ", 16, format, uint16(channels),
+			uint32(rate), uint32(rate)*uint32(align), align, uint16(bits)))
+	}
+	buf.Write(chunk(binary.LittleEndian, "data", dataSize))
+
+	return buf.Bytes()
+}
+
+func TestProbe(t *testing.T) {
+	rf64 := bytes.NewBufferString("RF64\xff\xff\xff\xffWAVE")
+	rf64.Write(chunk(binary.LittleEndian, "ds64", 28, uint64(0), uint64(6000000000), uint64(0), uint32(0)))
+	rf64.Write(wave(wavFormatPCM, 2, 96000, 24, -1)[12+12:])
+
+	aiff := bytes.NewBufferString("FORM\x00\x00\x00\x00AIFF")
+	// 44100 as 80-bit extended float.
+	aiff.Write(chunk(binary.BigEndian, "COMM", 18, uint16(2), uint32(441000), uint16(16),
+		[10]byte{0x40, 0x0E, 0xAC, 0x44}))
+
+	aifc := bytes.NewBufferString("FORM\x00\x00\x00\x00AIFC")
+	aifc.Write(chunk(binary.BigEndian, "FVER", 4, uint32(0xA2805140)))
+	aifc.Write(chunk(binary.BigEndian, "COMM", 24, uint16(1), uint32(48000), uint16(24),
+		[10]byte{0x40, 0x0E, 0xBB, 0x80}, [4]byte{'N', 'O', 'N', 'E'}, uint16(0)))
+
+	tests := []struct {
+		data     []byte
+		fileType FileType
+		info     AudioInfo
+	}{
+		{wave(wavFormatPCM, 2, 44100, 16, 44100*4*3+588*4*10),
+			FileTypeWave, AudioInfo{44100, 2, 16, 44100*3 + 5880, Time{0, 3, 10}, FileTypeWave, false, 56}},
+		{wave(wavFormatExtensible, 6, 48000, 24, 48000*18*60),
+			FileTypeWave, AudioInfo{48000, 6, 24, 48000 * 60, Time{1, 0, 0}, FileTypeWave, false, 80}},
+		{rf64.Bytes(),
+			FileTypeWave, AudioInfo{96000, 2, 24, 1000000000, Time{173, 36, 50}, FileTypeWave, false, 80}},
+		{aiff.Bytes(),
+			FileTypeAiff, AudioInfo{44100, 2, 16, 441000, Time{0, 10, 0}, FileTypeAiff, false, 0}},
+		{aifc.Bytes(),
+			FileTypeAiff, AudioInfo{48000, 1, 24, 48000, Time{0, 1, 0}, FileTypeAiff, false, 0}},
+		{make([]byte, 2352*80),
+			FileTypeBinary, AudioInfo{44100, 2, 16, 588 * 80, Time{0, 1, 5}, FileTypeBinary, false, 0}},
+		{make([]byte, 2352*75),
+			FileTypeMotorola, AudioInfo{44100, 2, 16, 588 * 75, Time{0, 1, 0}, FileTypeMotorola, false, 0}},
+	}
+
+	for i, test := range tests {
+		info, err := Probe(bytes.NewReader(test.data), test.fileType)
+		if err != nil {
+			t.Fatalf("Failed to probe file %d. %s", i, err.Error())
+		}
+		if *info != test.info {
+			t.Fatalf("Probed %+v but %+v expected", *info, test.info)
+		}
+	}
+
+	if _, err := Probe(strings.NewReader("ID3 something"), FileTypeMp3); err == nil {
+		t.Fatalf("MP3 file is probed")
+	}
+	info, err := Probe(bytes.NewReader(wave(wavFormatFloat, 2, 44100, 32, 100)), FileTypeWave)
+	if err != nil || !info.Float {
+		t.Fatalf("Float WAVE is not recognised: %+v. %v", info, err)
+	}
+	if _, err := Probe(bytes.NewReader(wave(0x55, 2, 44100, 16, 100)), FileTypeWave); err == nil {
+		t.Fatalf("Compressed WAVE is probed")
+	}
+}
+
+func TestProbeSubFormat(t *testing.T) {
+	guid := func(format uint16) [16]byte {
+		var g [16]byte
+		binary.LittleEndian.PutUint16(g[:], format)
+		copy(g[2:], wavSubFormatGUID[:])
+		return g
+	}
+	other := guid(wavFormatPCM)
+	other[15] = 0
+
+	tests := []struct {
+		guid  [16]byte
+		ok    bool
+		float bool
+	}{
+		{guid(wavFormatPCM), true, false},
+		{guid(wavFormatFloat), true, true},
+		{guid(0x55), false, false},
+		{other, false, false},
+		{[16]byte{}, false, false},
+	}
+
+	for _, test := range tests {
+		data := waveGUID(wavFormatExtensible, test.guid, 2, 44100, 32, 100)
+		info, err := Probe(bytes.NewReader(data), FileTypeWave)
+		if (err == nil) != test.ok {
+			t.Fatalf("Sub-format %X is probed: %v", test.guid, err)
+		}
+		if err == nil && info.Float != test.float {
+			t.Fatalf("Sub-format %X is probed as %+v", test.guid, info)
+		}
+	}
+}
+
+func TestProbeLengths(t *testing.T) {
+	fsys := fstest.MapFS{
+		"01.wav": {Data: wave(wavFormatPCM, 2, 44100, 16, 588*4*18150)},
+		"02.wav": {Data: wave(wavFormatPCM, 2, 44100, 16, 588*4*22500)},
+	}
+	sheet, err := Parse(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	lengths, err := ProbeLengths(fsys, sheet)
+	if err != nil {
+		t.Fatalf("Failed to probe. %s", err.Error())
+	}
+	spans, err := sheet.TrackSpans(lengths)
+	if err != nil {
+		t.Fatalf("Failed to compute spans. %s", err.Error())
+	}
+	if spans[2].End != (Time{5, 0, 0}) || spans[1].Pregap != (Time{0, 2, 0}) {
+		t.Fatalf("Unexpected spans %+v", spans)
+	}
+
+	delete(fsys, "02.wav")
+	if _, err := ProbeLengths(fsys, sheet); err == nil {
+		t.Fatalf("Missing file is probed")
+	}
+}
+
+func TestProbeCodecs(t *testing.T) {
+	le := binary.LittleEndian
+	write := func(buf *bytes.Buffer, order binary.ByteOrder, fields ...interface{}) []byte {
+		for _, f := range fields {
+			binary.Write(buf, order, f)
+		}
+		return buf.Bytes()
+	}
+
+	flac := write(bytes.NewBufferString("fLaC"), binary.BigEndian, [4]byte{0x80, 0, 0, 34}, [10]byte{},
+		uint64(44100)<<44|uint64(1)<<41|uint64(15)<<36|uint64(44100*60), [16]byte{})
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x01\x00"), make([]byte, 128)...)
+
+	ape := write(bytes.NewBufferString("MAC "), le, uint16(3990), uint16(0), uint32(52), [40]byte{},
+		uint16(2000), uint16(0), uint32(73728*4), uint32(1000), uint32(3),
+		uint16(16), uint16(2), uint32(44100))
+	oldApe := write(bytes.NewBufferString("MAC "), le, uint16(3970),
+		uint16(2000), uint16(8), uint16(2), uint32(44100), uint32(0), uint32(0),
+		uint32(2), uint32(500))
+
+	wv := write(bytes.NewBufferString("wvpk"), le, uint32(24), uint16(0x410), uint8(0), uint8(0),
+		uint32(441000), uint32(0), uint32(22050), uint32(1|9<<23), uint32(0))
+	// Custom sample rate 50000 and 6 channels.
+	wvMeta := write(bytes.NewBufferString("wvpk"), le, uint32(24+6+4), uint16(0x410), uint8(0), uint8(1),
+		uint32(0), uint32(0), uint32(22050), uint32(1|15<<23), uint32(0),
+		[]byte{0x67, 2, 0x50, 0xC3, 0x00, 0}, []byte{0x0D, 1, 6, 0})
+
+	tests := []struct {
+		data []byte
+		info AudioInfo
+	}{
+		{flac, AudioInfo{44100, 2, 16, 44100 * 60, Time{1, 0, 0}, FileTypeFlac, false, 0}},
+		{append(id3, flac...), AudioInfo{44100, 2, 16, 44100 * 60, Time{1, 0, 0}, FileTypeFlac, false, 0}},
+		{ape, AudioInfo{44100, 2, 16, 2*73728*4 + 1000, Time{0, 13, 29}, FileTypeApe, false, 0}},
+		{oldApe, AudioInfo{44100, 2, 24, 73728*4 + 500, Time{0, 6, 52}, FileTypeApe, false, 0}},
+		{wv, AudioInfo{44100, 2, 16, 441000, Time{0, 10, 0}, FileTypeWavPack, false, 0}},
+		{wvMeta, AudioInfo{50000, 6, 16, 1 << 32, Time{1431, 39, 25}, FileTypeWavPack, false, 0}},
+	}
+
+	for i, test := range tests {
+		info, err := Probe(bytes.NewReader(test.data), FileTypeWave)
+		if err != nil {
+			t.Fatalf("Failed to probe file %d. %s", i, err.Error())
+		}
+		if *info != test.info {
+			t.Fatalf("Probed %+v but %+v expected", *info, test.info)
+		}
+	}
+}
+
+func TestParseCodecFileType(t *testing.T) {
+	input := "FILE \"a.flac\" FLAC\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n"
+
+	if _, err := Parse(strings.NewReader(input)); err == nil {
+		t.Fatalf("Non-standard file type is accepted in the strict mode")
+	}
+	doc, err := ParseWithOptions(strings.NewReader(input), ParseOptions{})
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	if doc.Sheet.Files[0].Type != FileTypeFlac || len(doc.Warnings) != 1 {
+		t.Fatalf("Unexpected file %+v. %v", doc.Sheet.Files[0], doc.Warnings)
+	}
+	assertDocument(t, doc, input)
+}
@@ -0,0 +1,164 @@
+package cue
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// isRemKey returns true if the word can be a key of "REM KEY value" comment:
+// upper case latin letters, digits and underscores starting with a letter.
+func isRemKey(word string) bool {
+	if word == "" || word[0] < 'A' || word[0] > 'Z' {
+		return false
+	}
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isRemMetadata returns true if REM command parameters are "KEY value" pair
+// rather than free-form comment.
+func isRemMetadata(params []string) bool {
+	return len(params) >= 2 && isRemKey(params[0])
+}
+
+// set saves REM KEY value. Values of the well-known keys which
+// can't be parsed are saved as values of unknown keys.
+func (rem *Rem) set(key string, values []string) {
+	value := strings.Join(values, " ")
+
+	switch key {
+	case "GENRE":
+		rem.Genre = value
+		return
+	case "DATE":
+		rem.Date = value
+		return
+	case "DISCID":
+		rem.DiscID = value
+		return
+	case "COMMENT":
+		rem.Comment = value
+		return
+	case "COMPOSER":
+		rem.Composer = value
+		return
+	case "DISCNUMBER":
+		if n, err := strconv.Atoi(value); err == nil {
+			rem.DiscNumber = n
+			return
+		}
+	case "TOTALDISCS":
+		if n, err := strconv.Atoi(value); err == nil {
+			rem.TotalDiscs = n
+			return
+		}
+	case "REPLAYGAIN_ALBUM_GAIN":
+		if parseReplayGain(&rem.AlbumGain, values, true) {
+			return
+		}
+	case "REPLAYGAIN_ALBUM_PEAK":
+		if parseReplayGain(&rem.AlbumGain, values, false) {
+			return
+		}
+	case "REPLAYGAIN_TRACK_GAIN":
+		if parseReplayGain(&rem.TrackGain, values, true) {
+			return
+		}
+	case "REPLAYGAIN_TRACK_PEAK":
+		if parseReplayGain(&rem.TrackGain, values, false) {
+			return
+		}
+	}
+
+	if rem.Other == nil {
+		rem.Other = make(map[string][]string)
+	}
+	rem.Other[key] = append(rem.Other[key], value)
+}
+
+// parseReplayGain parses "-7.89 dB" gain or "0.988525" peak value
+// into the given ReplayGain, which is created if needed.
+func parseReplayGain(rg **ReplayGain, values []string, gain bool) bool {
+	if gain && len(values) == 2 && strings.EqualFold(values[1], "dB") {
+		values = values[:1]
+	}
+	if len(values) != 1 {
+		return false
+	}
+
+	f, err := strconv.ParseFloat(values[0], 64)
+	if err != nil {
+		return false
+	}
+
+	if *rg == nil {
+		*rg = new(ReplayGain)
+	}
+	if gain {
+		(*rg).Gain = &f
+	} else {
+		(*rg).Peak = &f
+	}
+
+	return true
+}
+
+// remCommands returns REM commands for all the metadata.
+func remCommands(level int, rem *Rem) []command {
+	var cmds []command
+	add := func(key string, value string) {
+		if value != "" {
+			cmds = append(cmds, newCommand(level, "REM", key, value))
+		}
+	}
+	addGain := func(prefix string, rg *ReplayGain) {
+		if rg == nil {
+			return
+		}
+		if rg.Gain != nil {
+			cmds = append(cmds, newCommand(level, "REM", prefix+"_GAIN", formatFloat(*rg.Gain), "dB"))
+		}
+		if rg.Peak != nil {
+			cmds = append(cmds, newCommand(level, "REM", prefix+"_PEAK", formatFloat(*rg.Peak)))
+		}
+	}
+
+	add("GENRE", rem.Genre)
+	add("DATE", rem.Date)
+	add("DISCID", rem.DiscID)
+	add("COMMENT", rem.Comment)
+	if rem.DiscNumber != 0 {
+		add("DISCNUMBER", strconv.Itoa(rem.DiscNumber))
+	}
+	if rem.TotalDiscs != 0 {
+		add("TOTALDISCS", strconv.Itoa(rem.TotalDiscs))
+	}
+	add("COMPOSER", rem.Composer)
+	addGain("REPLAYGAIN_ALBUM", rem.AlbumGain)
+	addGain("REPLAYGAIN_TRACK", rem.TrackGain)
+
+	keys := make([]string, 0, len(rem.Other))
+	for key := range rem.Other {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range rem.Other[key] {
+			cmds = append(cmds, newCommand(level, "REM", key, value))
+		}
+	}
+
+	return cmds
+}
+
+// formatFloat returns the shortest string representation of the number.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
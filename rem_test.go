@@ -0,0 +1,152 @@
+package cue
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemParse(t *testing.T) {
+	file, err := os.Open("test.cue")
+	if err != nil {
+		t.Fatalf("Failed to open file. %s", err.Error())
+	}
+	defer file.Close()
+
+	sheet, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Failed to parse file. %s", err.Error())
+	}
+
+	etalon := Rem{
+		Genre:   "Hard Rock",
+		Date:    "1990",
+		DiscID:  "840A130A",
+		Comment: "ExactAudioCopy v0.95b4",
+	}
+	if !reflect.DeepEqual(sheet.Rem, etalon) {
+		t.Fatalf("Parsed %+v but %+v expected", sheet.Rem, etalon)
+	}
+	if len(sheet.Comments) != 0 {
+		t.Fatalf("Unexpected comments %v", sheet.Comments)
+	}
+}
+
+func TestRemTyped(t *testing.T) {
+	input := "REM DISCNUMBER 2\n" +
+		"REM TOTALDISCS two\n" +
+		"REM REPLAYGAIN_ALBUM_GAIN -7.89 dB\n" +
+		"REM REPLAYGAIN_ALBUM_PEAK 0.988525\n" +
+		"REM LABEL \"Vertigo\"\n" +
+		"REM LABEL Mercury\n" +
+		"REM ripped by me\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	etalon := Rem{
+		DiscNumber: 2,
+		AlbumGain:  &ReplayGain{Gain: float(-7.89), Peak: float(0.988525)},
+		Other: map[string][]string{
+			"TOTALDISCS": {"two"},
+			"LABEL":      {"Vertigo", "Mercury"},
+		},
+	}
+	if !reflect.DeepEqual(sheet.Rem, etalon) {
+		t.Fatalf("Parsed %+v but %+v expected", sheet.Rem, etalon)
+	}
+	if !reflect.DeepEqual(sheet.Comments, []string{"ripped by me"}) {
+		t.Fatalf("Unexpected comments %v", sheet.Comments)
+	}
+
+	assertRoundTrip(t, sheet)
+}
+
+func TestRemDocument(t *testing.T) {
+	input := "REM ripped by me\n" +
+		"REM COMMENT \"EAC\"\n" +
+		"REM GENRE Rock\n"
+
+	doc, err := ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	doc.Sheet.Rem.Genre = "Hard Rock"
+
+	etalon := "REM ripped by me\n" +
+		"REM COMMENT \"EAC\"\n" +
+		"REM GENRE \"Hard Rock\"\n"
+	assertDocument(t, doc, etalon)
+}
+
+func TestRemTrack(t *testing.T) {
+	input := "REM GENRE Rock\n" +
+		"FILE \"a.wav\" WAVE\n" +
+		"REM DATE 1990\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    TITLE \"One\"\n" +
+		"    REM COMPOSER \"Somebody Else\"\n" +
+		"    REM REPLAYGAIN_TRACK_GAIN -1.5 dB\n" +
+		"    REM REPLAYGAIN_TRACK_PEAK 0.9\n" +
+		"    REM live version\n" +
+		"    INDEX 01 00:00:00\n"
+
+	doc, err := ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	sheet := doc.Sheet
+	if sheet.Rem.Genre != "Rock" || sheet.Rem.Date != "1990" {
+		t.Fatalf("Disc REM is not filled: %+v", sheet.Rem)
+	}
+	track := &sheet.Files[0].Tracks[0]
+	etalon := Rem{Composer: "Somebody Else", TrackGain: &ReplayGain{float(-1.5), float(0.9)}}
+	if !reflect.DeepEqual(track.Rem, etalon) {
+		t.Fatalf("Parsed %+v but %+v expected", track.Rem, etalon)
+	}
+	if !reflect.DeepEqual(track.Comments, []string{"live version"}) {
+		t.Fatalf("Unexpected track comments %v", track.Comments)
+	}
+
+	assertRoundTrip(t, sheet)
+
+	*track.Rem.TrackGain.Gain = -2
+	assertDocument(t, doc, strings.Replace(input, "-1.5 dB", "-2 dB", 1))
+}
+
+func TestRemPeakOnly(t *testing.T) {
+	input := "REM REPLAYGAIN_ALBUM_PEAK 0\n" +
+		"FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    REM REPLAYGAIN_TRACK_PEAK 0.9\n" +
+		"    INDEX 01 00:00:00\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	if !reflect.DeepEqual(sheet.Rem.AlbumGain, &ReplayGain{Peak: float(0)}) {
+		t.Fatalf("Parsed %+v album gain", sheet.Rem.AlbumGain)
+	}
+	track := &sheet.Files[0].Tracks[0]
+	if !reflect.DeepEqual(track.Rem.TrackGain, &ReplayGain{Peak: float(0.9)}) {
+		t.Fatalf("Parsed %+v track gain", track.Rem.TrackGain)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := Write(buf, sheet); err != nil {
+		t.Fatalf("Failed to write. %s", err.Error())
+	}
+	if buf.String() != input {
+		t.Fatalf("Written %q but %q expected", buf.String(), input)
+	}
+}
+
+func float(f float64) *float64 {
+	return &f
+}
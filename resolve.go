@@ -0,0 +1,156 @@
+package cue
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// DefaultExtensions are extensions tried by Resolve when the file
+// referenced by the sheet doesn't exist, e.g. after it was transcoded.
+var DefaultExtensions = []string{".flac", ".ape", ".wv", ".wav", ".aiff", ".aif"}
+
+// ResolveOptions configures files resolving.
+type ResolveOptions struct {
+	// Extensions (with leading dot) tried in the given order when
+	// the referenced file doesn't exist. nil means DefaultExtensions.
+	Extensions []string
+}
+
+// ResolveError lists files referenced by the sheet but not found.
+type ResolveError struct {
+	// Names of the files as they are written in the sheet.
+	Names []string
+}
+
+// Error implements error interface.
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("Files not found: %s.", strings.Join(e.Names, ", "))
+}
+
+// Resolve finds files referenced by the sheet located in the dir of fsys.
+// Windows paths are accepted, names are matched case-insensitively if there
+// is no exact match and files with DefaultExtensions are tried when the
+// referenced one doesn't exist. Files which are not found in the referenced
+// directory are looked for in the dir. Returned paths are paths in fsys
+// for every sheet file. Unnamed files are never found. Paths of the files
+// not found are empty and *ResolveError listing them is returned.
+func Resolve(fsys fs.FS, dir string, sheet *CueSheet) ([]string, error) {
+	return ResolveWithOptions(fsys, dir, sheet, ResolveOptions{})
+}
+
+// ResolveWithOptions is like Resolve but uses the given options.
+func ResolveWithOptions(fsys fs.FS, dir string, sheet *CueSheet, opts ResolveOptions) ([]string, error) {
+	exts := opts.Extensions
+	if exts == nil {
+		exts = DefaultExtensions
+	}
+
+	paths := make([]string, len(sheet.Files))
+	var missing []string
+	for i, file := range sheet.Files {
+		name := strings.ReplaceAll(file.Name, "\\", "/")
+		// Drive letter of the Windows path.
+		if len(name) >= 2 && name[1] == ':' {
+			name = name[2:]
+		}
+		// Unnamed file (e.g. tracks without FILE command) can't be found,
+		// it is not looked up as the dir itself.
+		if strings.Trim(name, "/") == "" {
+			missing = append(missing, file.Name)
+			continue
+		}
+
+		var p string
+		if !strings.HasPrefix(name, "/") {
+			p = lookupFile(fsys, path.Join(dir, name), exts)
+		}
+		if p == "" {
+			p = lookupFile(fsys, path.Join(dir, path.Base(name)), exts)
+		}
+		if p == "" {
+			missing = append(missing, file.Name)
+		}
+		paths[i] = p
+	}
+
+	if len(missing) > 0 {
+		return paths, &ResolveError{missing}
+	}
+
+	return paths, nil
+}
+
+// lookupFile returns path of the existing file matching the given one.
+// Returns empty string if there is no such file.
+func lookupFile(fsys fs.FS, name string, exts []string) string {
+	if !fs.ValidPath(name) || name == "." {
+		return ""
+	}
+
+	dir, base := path.Split(name)
+	dir = lookupDir(fsys, path.Clean(dir))
+	if dir == "" {
+		return ""
+	}
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return ""
+	}
+
+	// Candidate names in the order of preference.
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	candidates := []string{base}
+	for _, ext := range exts {
+		candidates = append(candidates, stem+ext)
+	}
+
+	for _, candidate := range candidates {
+		var folded string
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			if entry.Name() == candidate {
+				return path.Join(dir, entry.Name())
+			}
+			if folded == "" && strings.EqualFold(entry.Name(), candidate) {
+				folded = entry.Name()
+			}
+		}
+		if folded != "" {
+			return path.Join(dir, folded)
+		}
+	}
+
+	return ""
+}
+
+// lookupDir returns path of the existing directory matching the given one
+// case-insensitively. Returns empty string if there is no such directory.
+func lookupDir(fsys fs.FS, dir string) string {
+	if dir == "." {
+		return dir
+	}
+	if fi, err := fs.Stat(fsys, dir); err == nil && fi.IsDir() {
+		return dir
+	}
+
+	parent, base := path.Split(dir)
+	parent = lookupDir(fsys, path.Clean(parent))
+	if parent == "" {
+		return ""
+	}
+	entries, err := fs.ReadDir(fsys, parent)
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(entry.Name(), base) {
+			return path.Join(parent, entry.Name())
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,56 @@
+package cue
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolve(t *testing.T) {
+	fsys := fstest.MapFS{
+		"music/album/01.flac":         {},
+		"music/album/02.wav":          {},
+		"music/album/Track 03.WAV":    {},
+		"music/album/CD2/04.ape":      {},
+		"music/album/05.wav":          {},
+		"music/album/05.flac":         {},
+		"music/album/06.wv":           {},
+		"music/album/album.cue":       {},
+		"music/album/subdir/07.wav/x": {},
+		"music/album.flac":            {},
+	}
+	sheet := &CueSheet{}
+	for _, name := range []string{"01.wav", "02.wav", "track 03.wav", "cd2\\04.wav", "05.wav",
+		"C:\\Rips\\Album\\06.wav", "subdir/07.wav", "../08.wav", "", "C:\\"} {
+		sheet.Files = append(sheet.Files, File{Name: name, Type: FileTypeWave})
+	}
+
+	paths, err := Resolve(fsys, "music/album", sheet)
+	etalon := []string{
+		"music/album/01.flac",
+		"music/album/02.wav",
+		"music/album/Track 03.WAV",
+		"music/album/CD2/04.ape",
+		"music/album/05.wav",
+		"music/album/06.wv",
+		"",
+		"",
+		"",
+		"",
+	}
+	if !reflect.DeepEqual(paths, etalon) {
+		t.Fatalf("Resolved %q but %q expected", paths, etalon)
+	}
+
+	var re *ResolveError
+	if !errors.As(err, &re) || !reflect.DeepEqual(re.Names, []string{"subdir/07.wav", "../08.wav", "", "C:\\"}) {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	paths, err = ResolveWithOptions(fsys, "music/album", sheet,
+		ResolveOptions{Extensions: []string{".wav"}})
+	if err == nil || paths[0] != "" || paths[4] != "music/album/05.wav" {
+		t.Fatalf("Extensions option is ignored: %q", paths)
+	}
+}
@@ -10,14 +10,49 @@ type CueSheet struct {
 	Title string
 	// Specify songwriter for disc.
 	Songwriter string
-	// Comments in the CUE SHEET file.
+	// Free-form comments in the CUE SHEET file.
 	Comments []string
+	// Metadata stored in REM comments.
+	Rem Rem
 	// Name of the file that contains the encoded CD-TEXT information for the disc.
 	CdTextFile string
 	// Data/audio files descibed byt the cue-file.
 	Files []File
 }
 
+// Metadata stored in "REM KEY value" comments. This is not a part of the cue
+// sheet specification but is widely used by rippers.
+type Rem struct {
+	// REM GENRE.
+	Genre string
+	// REM DATE, usually release year.
+	Date string
+	// REM DISCID, freedb disc identifier.
+	DiscID string
+	// REM COMMENT.
+	Comment string
+	// REM DISCNUMBER.
+	DiscNumber int
+	// REM TOTALDISCS.
+	TotalDiscs int
+	// REM COMPOSER.
+	Composer string
+	// REM REPLAYGAIN_ALBUM_GAIN and REPLAYGAIN_ALBUM_PEAK.
+	AlbumGain *ReplayGain
+	// REM REPLAYGAIN_TRACK_GAIN and REPLAYGAIN_TRACK_PEAK.
+	TrackGain *ReplayGain
+	// Values of all other keys.
+	Other map[string][]string
+}
+
+// ReplayGain values.
+type ReplayGain struct {
+	// Gain in dB, nil if it's not specified.
+	Gain *float64
+	// Peak amplitude, nil if it's not specified.
+	Peak *float64
+}
+
 // Type of the audio file.
 type FileType int
 
@@ -32,6 +67,12 @@ const (
 	FileTypeWave
 	// Audio MP3 file
 	FileTypeMp3
+	// Audio FLAC file. Not a part of the specification.
+	FileTypeFlac
+	// Audio Monkey's Audio (APE) file. Not a part of the specification.
+	FileTypeApe
+	// Audio WavPack file. Not a part of the specification.
+	FileTypeWavPack
 )
 
 // Track datatype.
@@ -39,7 +80,7 @@ type TrackDataType int
 
 const (
 	// AUDIO – Audio/Music (2352)
-	DataTypeAudio = iota
+	DataTypeAudio TrackDataType = iota
 	// CDG – Karaoke CD+G (2448)
 	DataTypeCdg
 	// MODE1/2048 – CDROM Mode1 Data (cooked)
@@ -77,6 +118,10 @@ type Index struct {
 	Number int
 	// Index starting time.
 	Time Time
+	// Number of files between the track's file and the file of the index.
+	// Track can continue in the next FILE, e.g. when track pregap
+	// (INDEX 00) is appended to the end of the previous track's file.
+	FileOffset int
 }
 
 // Additional decode information about track.
@@ -84,7 +129,7 @@ type TrackFlag int
 
 const (
 	// Digital copy permitted.
-	TrackFlagDcp = iota
+	TrackFlagDcp TrackFlag = iota
 	// Four channel audio.
 	TrackFlag4ch
 	// Pre-emphasis enabled (audio tracks only).
@@ -114,6 +159,10 @@ type Track struct {
 	Pregap Time
 	// Length of the track postgap.
 	Postgap Time
+	// Free-form comments in the track block.
+	Comments []string
+	// Metadata stored in REM comments in the track block.
+	Rem Rem
 }
 
 // Audio file representation structure.
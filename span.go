@@ -0,0 +1,138 @@
+package cue
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TrackSpan describes where the track audio is placed in the sheet files.
+type TrackSpan struct {
+	// Track the span describes.
+	Track *Track
+	// Index in CueSheet.Files of the file with the track INDEX 01.
+	File int
+	// Track start (INDEX 01) in the file.
+	Start Time
+	// Track end in the same file: the next track's INDEX 00 if there is one
+	// or its INDEX 01 otherwise. The last track of the file ends with the file.
+	End Time
+	// EndKnown is false if the track ends with the file of unknown length.
+	EndKnown bool
+	// Pregap length: PREGAP silence followed by audio between the track's
+	// INDEX 00 and INDEX 01.
+	Pregap Time
+	// PregapKnown is false if the pregap starts in the file of unknown length.
+	PregapKnown bool
+	// Postgap length.
+	Postgap Time
+}
+
+// Length returns length of the track audio without pregap and postgap.
+// Zero is returned if the track end is unknown.
+func (span *TrackSpan) Length() Time {
+	if !span.EndKnown {
+		return Time{}
+	}
+
+	return span.End.Sub(span.Start)
+}
+
+// position is a point in the sheet files.
+type position struct {
+	file int
+	time Time
+}
+
+// TrackSpans returns spans of all the sheet tracks. lengths[i] is the length
+// of the i-th file of the sheet, it is needed to close the last track of the
+// file and to measure pregaps which start in the previous file. lengths can
+// be shorter than Files and zero length means length is unknown, ends and
+// pregaps which can't be computed are left unknown.
+func (sheet *CueSheet) TrackSpans(lengths []Time) ([]TrackSpan, error) {
+	var spans []TrackSpan
+	// Beginning of every track: INDEX 00 or INDEX 01 position.
+	var starts []position
+
+	for i := range sheet.Files {
+		for j := range sheet.Files[i].Tracks {
+			track := &sheet.Files[i].Tracks[j]
+			span := TrackSpan{Track: track, Postgap: track.Postgap}
+
+			var pregap *position
+			found := false
+			for _, index := range track.Indexes {
+				pos := position{i + index.FileOffset, index.Time}
+				if index.Number == 0 {
+					pregap = &pos
+				} else if index.Number == 1 {
+					span.File = pos.file
+					span.Start = pos.time
+					found = true
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("Track %d has no INDEX 01.", track.Number)
+			}
+
+			span.Pregap = track.Pregap
+			span.PregapKnown = true
+			start := position{span.File, span.Start}
+			if pregap != nil {
+				gap, err := distance(*pregap, start, lengths)
+				if errors.Is(err, errUnknownLength) {
+					span.Pregap = Time{}
+					span.PregapKnown = false
+				} else if err != nil {
+					return nil, fmt.Errorf("Failed to compute track %d pregap. %s",
+						track.Number, err.Error())
+				} else {
+					span.Pregap = span.Pregap.Add(gap)
+				}
+				start = *pregap
+			}
+
+			spans = append(spans, span)
+			starts = append(starts, start)
+		}
+	}
+
+	for i := range spans {
+		span := &spans[i]
+		if i+1 < len(starts) && starts[i+1].file == span.File {
+			span.End = starts[i+1].time
+			span.EndKnown = true
+		} else if span.File < len(lengths) && lengths[span.File] != (Time{}) {
+			span.End = lengths[span.File]
+			span.EndKnown = true
+		}
+
+		if span.EndKnown && span.End.Compare(span.Start) < 0 {
+			return nil, fmt.Errorf("Track %d ends before it starts.", span.Track.Number)
+		}
+	}
+
+	return spans, nil
+}
+
+// errUnknownLength is returned by distance if the positions are separated
+// by the file of unknown length.
+var errUnknownLength = errors.New("File length is unknown.")
+
+// distance returns time between two positions, which can be in different files.
+func distance(from position, to position, lengths []Time) (Time, error) {
+	var d Time
+
+	for f := from.file; f < to.file; f++ {
+		if f >= len(lengths) || lengths[f] == (Time{}) {
+			return Time{}, errUnknownLength
+		}
+		d = d.Add(lengths[f])
+	}
+	d = d.Add(to.time).Sub(from.time)
+
+	if d.Compare(Time{}) < 0 {
+		return Time{}, fmt.Errorf("Negative distance between indexes.")
+	}
+
+	return d, nil
+}
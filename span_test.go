@@ -0,0 +1,127 @@
+package cue
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// gapsAppended is a sheet with pregaps appended to the previous files.
+const gapsAppended = "FILE \"01.wav\" WAVE\n" +
+	"  TRACK 01 AUDIO\n" +
+	"    INDEX 01 00:00:00\n" +
+	"  TRACK 02 AUDIO\n" +
+	"    INDEX 00 04:00:00\n" +
+	"FILE \"02.wav\" WAVE\n" +
+	"    INDEX 01 00:00:00\n" +
+	"  TRACK 03 AUDIO\n" +
+	"    INDEX 00 02:59:00\n" +
+	"    INDEX 01 03:00:00\n"
+
+func TestTrackSpans(t *testing.T) {
+	input := "FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 00 00:00:00\n" +
+		"    INDEX 01 00:01:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    PREGAP 00:02:00\n" +
+		"    INDEX 01 03:00:00\n" +
+		"    POSTGAP 00:01:00\n" +
+		"  TRACK 03 AUDIO\n" +
+		"    INDEX 00 05:00:00\n" +
+		"    INDEX 01 05:02:00\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	spans, err := sheet.TrackSpans(nil)
+	if err != nil {
+		t.Fatalf("Failed to compute spans. %s", err.Error())
+	}
+	tracks := sheet.Files[0].Tracks
+	etalon := []TrackSpan{
+		{&tracks[0], 0, Time{0, 1, 0}, Time{3, 0, 0}, true, Time{0, 1, 0}, true, Time{}},
+		{&tracks[1], 0, Time{3, 0, 0}, Time{5, 0, 0}, true, Time{0, 2, 0}, true, Time{0, 1, 0}},
+		{&tracks[2], 0, Time{5, 2, 0}, Time{}, false, Time{0, 2, 0}, true, Time{}},
+	}
+	if !reflect.DeepEqual(spans, etalon) {
+		t.Fatalf("Computed %+v but %+v expected", spans, etalon)
+	}
+
+	spans, err = sheet.TrackSpans([]Time{{7, 0, 0}})
+	if err != nil {
+		t.Fatalf("Failed to compute spans. %s", err.Error())
+	}
+	if spans[2].End != (Time{7, 0, 0}) || !spans[2].EndKnown || spans[2].Length() != (Time{1, 58, 0}) {
+		t.Fatalf("Last track is not closed with the file length: %+v", spans[2])
+	}
+}
+
+func TestTrackSpansZeroEnd(t *testing.T) {
+	input := "FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    INDEX 00 00:00:00\n" +
+		"    INDEX 01 00:02:00\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	spans, err := sheet.TrackSpans(nil)
+	if err != nil {
+		t.Fatalf("Failed to compute spans. %s", err.Error())
+	}
+	if spans[0].End != (Time{}) || !spans[0].EndKnown {
+		t.Fatalf("Track ending at 00:00:00 has unknown end: %+v", spans[0])
+	}
+	if spans[1].EndKnown {
+		t.Fatalf("Track ending with the file of unknown length has known end: %+v", spans[1])
+	}
+}
+
+func TestTrackSpansGapsAppended(t *testing.T) {
+	doc, err := ParseDocument(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	sheet := doc.Sheet
+
+	if len(sheet.Files[1].Tracks) != 1 {
+		t.Fatalf("INDEX after FILE doesn't continue the previous track")
+	}
+	assertDocument(t, doc, gapsAppended)
+	assertRoundTrip(t, sheet)
+
+	// Pregap in the file of unknown length and the last track end are unknown.
+	spans, err := sheet.TrackSpans(nil)
+	if err != nil {
+		t.Fatalf("Failed to compute spans. %s", err.Error())
+	}
+	if !spans[0].EndKnown || spans[1].PregapKnown || !spans[1].EndKnown ||
+		!spans[2].PregapKnown || spans[2].EndKnown {
+		t.Fatalf("Unexpected spans %+v", spans)
+	}
+
+	spans, err = sheet.TrackSpans([]Time{{4, 2, 0}, {5, 0, 0}})
+	if err != nil {
+		t.Fatalf("Failed to compute spans. %s", err.Error())
+	}
+	bounds := [][2]Time{
+		{{0, 0, 0}, {4, 0, 0}},
+		{{0, 0, 0}, {2, 59, 0}},
+		{{3, 0, 0}, {5, 0, 0}},
+	}
+	pregaps := []Time{{}, {0, 2, 0}, {0, 1, 0}}
+	files := []int{0, 1, 1}
+	for i, span := range spans {
+		if span.File != files[i] || span.Start != bounds[i][0] ||
+			span.End != bounds[i][1] || span.Pregap != pregaps[i] || !span.PregapKnown {
+			t.Fatalf("Unexpected span of track %d: %+v", i+1, span)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package cue
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// name returns FILE command type name.
+func (t FileType) name() (string, bool) {
+	for name, ft := range fileTypes {
+		if ft == t {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// String returns FILE command type name, e.g. "WAVE".
+func (t FileType) String() string {
+	if name, ok := t.name(); ok {
+		return name
+	}
+
+	return fmt.Sprintf("FileType(%d)", int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (t FileType) MarshalText() ([]byte, error) {
+	name, ok := t.name()
+	if !ok {
+		return nil, fmt.Errorf("Unknown file type %d.", int(t))
+	}
+
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (t *FileType) UnmarshalText(text []byte) error {
+	ft, ok := fileTypes[string(text)]
+	if !ok {
+		return fmt.Errorf("Unknown file type %s.", text)
+	}
+	*t = ft
+
+	return nil
+}
+
+// name returns TRACK command datatype name.
+func (t TrackDataType) name() (string, bool) {
+	for name, dt := range trackDataTypes {
+		if dt == t {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// String returns TRACK command datatype name, e.g. "MODE1/2352".
+func (t TrackDataType) String() string {
+	if name, ok := t.name(); ok {
+		return name
+	}
+
+	return fmt.Sprintf("TrackDataType(%d)", int(t))
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (t TrackDataType) MarshalText() ([]byte, error) {
+	name, ok := t.name()
+	if !ok {
+		return nil, fmt.Errorf("Unknown track datatype %d.", int(t))
+	}
+
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (t *TrackDataType) UnmarshalText(text []byte) error {
+	dt, ok := trackDataTypes[string(text)]
+	if !ok {
+		return fmt.Errorf("Unknown track datatype %s.", text)
+	}
+	*t = dt
+
+	return nil
+}
+
+// name returns FLAGS command parameter.
+func (f TrackFlag) name() (string, bool) {
+	for name, tf := range trackFlags {
+		if tf == f {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+// String returns FLAGS command parameter, e.g. "DCP".
+func (f TrackFlag) String() string {
+	if name, ok := f.name(); ok {
+		return name
+	}
+
+	return fmt.Sprintf("TrackFlag(%d)", int(f))
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (f TrackFlag) MarshalText() ([]byte, error) {
+	name, ok := f.name()
+	if !ok {
+		return nil, fmt.Errorf("Unknown track flag %d.", int(f))
+	}
+
+	return []byte(name), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+func (f *TrackFlag) UnmarshalText(text []byte) error {
+	tf, ok := trackFlags[string(text)]
+	if !ok {
+		return fmt.Errorf("Unknown track flag %s.", text)
+	}
+	*f = tf
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+// Time is written in mm:ss:ff format, negative time is prefixed with "-".
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler interface.
+// Time is parsed from mm:ss:ff format, negative time is prefixed with "-".
+func (t *Time) UnmarshalText(text []byte) error {
+	s, negative := strings.CutPrefix(string(text), "-")
+	min, sec, frames, err := parseTime(s)
+	if err != nil {
+		return err
+	}
+	*t = Time{min, sec, frames}
+	if negative {
+		*t = TimeFromFrames(-t.TotalFrames())
+	}
+
+	return nil
+}
+
+// jsonCueSheet is CueSheet without methods, so it is encoded
+// as JSON object.
+type jsonCueSheet CueSheet
+
+// MarshalJSON implements json.Marshaler interface. Sheet is encoded
+// as JSON object, not as cue sheet text returned by MarshalText.
+func (sheet *CueSheet) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*jsonCueSheet)(sheet))
+}
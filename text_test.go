@@ -0,0 +1,108 @@
+package cue
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTextRoundTrip(t *testing.T) {
+	values := []struct {
+		value interface {
+			MarshalText() ([]byte, error)
+		}
+		text string
+	}{
+		{FileTypeWave, "WAVE"},
+		{FileTypeWavPack, "WAVPACK"},
+		{DataTypeAudio, "AUDIO"},
+		{DataTypeMode1_2352, "MODE1/2352"},
+		{TrackFlagDcp, "DCP"},
+		{TrackFlag4ch, "4CH"},
+		{Time{4, 31, 7}, "04:31:07"},
+		{TimeFromFrames(-13485), "-02:59:60"},
+	}
+
+	for _, v := range values {
+		text, err := v.value.MarshalText()
+		if err != nil || string(text) != v.text {
+			t.Fatalf("%v marshaled to %s but %s expected. %v", v.value, text, v.text, err)
+		}
+		if s, ok := v.value.(interface{ String() string }); ok && s.String() != v.text {
+			t.Fatalf("String of %v is %s", v.value, s.String())
+		}
+	}
+
+	var ft FileType
+	var dt TrackDataType
+	var tf TrackFlag
+	var tm Time
+	if err := ft.UnmarshalText([]byte("MOTOROLA")); err != nil || ft != FileTypeMotorola {
+		t.Fatalf("File type %v. %v", ft, err)
+	}
+	if err := dt.UnmarshalText([]byte("CDI/2336")); err != nil || dt != DataTypeCdi_2336 {
+		t.Fatalf("Datatype %v. %v", dt, err)
+	}
+	if err := tf.UnmarshalText([]byte("SCMS")); err != nil || tf != TrackFlagScms {
+		t.Fatalf("Flag %v. %v", tf, err)
+	}
+	if err := tm.UnmarshalText([]byte("01:02:03")); err != nil || tm != (Time{1, 2, 3}) {
+		t.Fatalf("Time %v. %v", tm, err)
+	}
+
+	if err := ft.UnmarshalText([]byte("OGG")); err == nil {
+		t.Fatalf("Unknown file type is unmarshaled")
+	}
+	if err := tm.UnmarshalText([]byte("-02:59:60")); err != nil || tm != TimeFromFrames(-13485) {
+		t.Fatalf("Time %v. %v", tm, err)
+	}
+	if text, err := tm.MarshalText(); err != nil || string(text) != "-02:59:60" {
+		t.Fatalf("Time %v marshaled to %s. %v", tm, text, err)
+	}
+
+	for _, s := range []string{"01:02:75", "01:-02:03", "01:02:-03", "--01:02:03", "-"} {
+		if err := tm.UnmarshalText([]byte(s)); err == nil {
+			t.Fatalf("Illegal time %s is unmarshaled", s)
+		}
+	}
+	if _, err := FileType(100).MarshalText(); err == nil {
+		t.Fatalf("Unknown file type is marshaled")
+	}
+	if s := TrackFlag(100).String(); s != "TrackFlag(100)" {
+		t.Fatalf("Unknown flag string is %s", s)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	file, err := os.Open("test.cue")
+	if err != nil {
+		t.Fatalf("Failed to open test.cue. %s", err.Error())
+	}
+	defer file.Close()
+	sheet, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	sheet.Files[0].Tracks[0].Flags = []TrackFlag{TrackFlagDcp, TrackFlagPre}
+
+	data, err := json.Marshal(sheet)
+	if err != nil {
+		t.Fatalf("Failed to marshal. %s", err.Error())
+	}
+	for _, s := range []string{`"Title":"Doro"`, `"Type":"WAVE"`, `"DataType":"AUDIO"`,
+		`"Flags":["DCP","PRE"]`, `"Time":"04:31:07"`} {
+		if !strings.Contains(string(data), s) {
+			t.Fatalf("%s doesn't contain %s", data, s)
+		}
+	}
+
+	result := new(CueSheet)
+	if err := json.Unmarshal(data, result); err != nil {
+		t.Fatalf("Failed to unmarshal. %s", err.Error())
+	}
+	if !reflect.DeepEqual(result, sheet) {
+		t.Fatalf("Unmarshaled %+v but %+v expected", result, sheet)
+	}
+}
@@ -0,0 +1,76 @@
+package cue
+
+import (
+	"time"
+)
+
+// Number of CD frames in one second.
+const FramesPerSecond = 75
+
+// TimeFromFrames returns time of the given number of CD frames.
+func TimeFromFrames(frames int) Time {
+	return Time{
+		Min:    frames / (60 * FramesPerSecond),
+		Sec:    frames / FramesPerSecond % 60,
+		Frames: frames % FramesPerSecond,
+	}
+}
+
+// FromDuration returns time of the given duration rounded to the nearest frame.
+func FromDuration(d time.Duration) Time {
+	if d < 0 {
+		return TimeFromFrames(-int((-d*FramesPerSecond + time.Second/2) / time.Second))
+	}
+
+	return TimeFromFrames(int((d*FramesPerSecond + time.Second/2) / time.Second))
+}
+
+// TotalFrames returns length in CD frames. Method can't be named Frames
+// because of the field with the same name.
+func (t Time) TotalFrames() int {
+	return (t.Min*60+t.Sec)*FramesPerSecond + t.Frames
+}
+
+// Add returns t+u.
+func (t Time) Add(u Time) Time {
+	return TimeFromFrames(t.TotalFrames() + u.TotalFrames())
+}
+
+// Sub returns t-u. Result is negative if u is greater than t.
+func (t Time) Sub(u Time) Time {
+	return TimeFromFrames(t.TotalFrames() - u.TotalFrames())
+}
+
+// Compare returns -1 if t is less than u, 1 if t is greater than u
+// and 0 if they are equal.
+func (t Time) Compare(u Time) int {
+	a, b := t.TotalFrames(), u.TotalFrames()
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// ToDuration returns time as time.Duration.
+func (t Time) ToDuration() time.Duration {
+	return time.Duration(t.TotalFrames()) * time.Second / FramesPerSecond
+}
+
+// Samples returns number of samples for the given sample rate.
+// Frame contains 588 samples of 44100 Hz audio.
+func (t Time) Samples(sampleRate int) int64 {
+	return int64(t.TotalFrames()) * int64(sampleRate) / FramesPerSecond
+}
+
+// String returns time in mm:ss:ff format.
+func (t Time) String() string {
+	if n := t.TotalFrames(); n < 0 {
+		return "-" + formatTime(TimeFromFrames(-n))
+	}
+
+	return formatTime(t)
+}
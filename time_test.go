@@ -0,0 +1,77 @@
+package cue
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeFrames(t *testing.T) {
+	tests := []struct {
+		time   Time
+		frames int
+		str    string
+	}{
+		{Time{0, 0, 0}, 0, "00:00:00"},
+		{Time{0, 0, 74}, 74, "00:00:74"},
+		{Time{0, 1, 0}, 75, "00:01:00"},
+		{Time{1, 0, 0}, 4500, "01:00:00"},
+		{Time{42, 59, 12}, 193437, "42:59:12"},
+		{Time{100, 0, 0}, 450000, "100:00:00"},
+	}
+
+	for _, test := range tests {
+		if n := test.time.TotalFrames(); n != test.frames {
+			t.Fatalf("%v has %d frames but %d expected", test.time, n, test.frames)
+		}
+		if tm := TimeFromFrames(test.frames); tm != test.time {
+			t.Fatalf("%d frames converted to %v but %v expected", test.frames, tm, test.time)
+		}
+		if s := test.time.String(); s != test.str {
+			t.Fatalf("%v formatted as %s but %s expected", test.time, s, test.str)
+		}
+	}
+}
+
+func TestTimeArithmetic(t *testing.T) {
+	a := Time{3, 59, 70}
+	b := Time{1, 0, 10}
+
+	if c := a.Add(b); c != (Time{5, 0, 5}) {
+		t.Fatalf("Add returned %v", c)
+	}
+	if c := a.Sub(b); c != (Time{2, 59, 60}) {
+		t.Fatalf("Sub returned %v", c)
+	}
+	if s := b.Sub(a).String(); s != "-02:59:60" {
+		t.Fatalf("Negative time formatted as %s", s)
+	}
+	if a.Compare(b) != 1 || b.Compare(a) != -1 || a.Compare(a) != 0 {
+		t.Fatalf("Wrong comparison result")
+	}
+}
+
+func TestTimeConversions(t *testing.T) {
+	tm := Time{1, 2, 3}
+
+	if d := tm.ToDuration(); d != 62040*time.Millisecond {
+		t.Fatalf("Converted to %s", d)
+	}
+	if c := FromDuration(62040 * time.Millisecond); c != tm {
+		t.Fatalf("Converted from duration to %v", c)
+	}
+	if c := FromDuration(62046 * time.Millisecond); c != tm {
+		t.Fatalf("Duration is not rounded to the nearest frame: %v", c)
+	}
+	if c := FromDuration(100 * time.Hour); c != (Time{6000, 0, 0}) {
+		t.Fatalf("Long duration converted to %v", c)
+	}
+	if c := FromDuration(-62046 * time.Millisecond); c != TimeFromFrames(-4653) {
+		t.Fatalf("Negative duration converted to %v", c)
+	}
+	if n := tm.Samples(44100); n != 4653*588 {
+		t.Fatalf("%d samples of 44100 Hz audio", n)
+	}
+	if n := tm.Samples(48000); n != 2977920 {
+		t.Fatalf("%d samples of 48000 Hz audio", n)
+	}
+}
@@ -0,0 +1,266 @@
+package cue
+
+import (
+	"fmt"
+)
+
+// TOC is a table of contents of the disc described by the sheet. All the
+// positions are absolute logical block addresses (LBA): numbers of frames
+// from the disc start, lead-in is not counted.
+type TOC struct {
+	// Disc tracks.
+	Tracks []TOCTrack
+	// Disc files.
+	Files []TOCFile
+	// Lead-out position, i.e. the disc length.
+	Leadout int
+	// Silence generated by PREGAP and POSTGAP commands.
+	gaps []tocGap
+}
+
+// TOCTrack is a track of the TOC.
+type TOCTrack struct {
+	// Track number.
+	Number int
+	// INDEX 01 position.
+	Offset int
+	// Track contains data but not audio.
+	Data bool
+	// Track indexes.
+	Indexes []TOCIndex
+	// Length of PREGAP silence preceding the first track index.
+	Pregap int
+	// Length of POSTGAP silence following the track.
+	Postgap int
+}
+
+// TOCIndex is a track index of the TOC.
+type TOCIndex struct {
+	// Index number.
+	Number int
+	// Index position.
+	Offset int
+}
+
+// TOCFile is a file of the TOC.
+type TOCFile struct {
+	// Position of the file start.
+	Offset int
+	// File length in frames.
+	Length int
+}
+
+// tocGap is generated silence inserted before the given position
+// of the concatenated files data.
+type tocGap struct {
+	data   int
+	length int
+}
+
+// TOC returns table of contents of the disc. lengths are lengths
+// of all the sheet files, so for the single-file sheet without PREGAP
+// and POSTGAP commands it is the lead-out position.
+func (sheet *CueSheet) TOC(lengths ...Time) (*TOC, error) {
+	if len(lengths) != len(sheet.Files) {
+		return nil, fmt.Errorf("Lengths of all %d files are required.", len(sheet.Files))
+	}
+
+	toc := new(TOC)
+	// Positions of the files in the concatenated files data.
+	starts := make([]int, len(lengths))
+	data := 0
+	for i, length := range lengths {
+		starts[i] = data
+		data += length.TotalFrames()
+	}
+
+	postgap := 0
+	for i := range sheet.Files {
+		for _, track := range sheet.Files[i].Tracks {
+			t := TOCTrack{
+				Number:  track.Number,
+				Offset:  -1,
+				Data:    track.DataType != DataTypeAudio && track.DataType != DataTypeCdg,
+				Pregap:  track.Pregap.TotalFrames(),
+				Postgap: track.Postgap.TotalFrames(),
+			}
+
+			for j, index := range track.Indexes {
+				f := i + index.FileOffset
+				if f >= len(lengths) || index.Time.TotalFrames() > lengths[f].TotalFrames() {
+					return nil, fmt.Errorf("Track %d index %d is beyond the end of the file.",
+						track.Number, index.Number)
+				}
+				pos := starts[f] + index.Time.TotalFrames()
+
+				// Previous track's postgap precedes the track.
+				if j == 0 && postgap != 0 {
+					toc.gaps = append(toc.gaps, tocGap{pos, postgap})
+					postgap = 0
+				}
+				// PREGAP silence precedes the first track index.
+				if j == 0 && t.Pregap != 0 {
+					toc.gaps = append(toc.gaps, tocGap{pos, t.Pregap})
+				}
+
+				offset := toc.lba(pos)
+				t.Indexes = append(t.Indexes, TOCIndex{index.Number, offset})
+				if index.Number == 1 {
+					t.Offset = offset
+				}
+			}
+			if t.Offset < 0 {
+				return nil, fmt.Errorf("Track %d has no INDEX 01.", track.Number)
+			}
+
+			postgap = t.Postgap
+			toc.Tracks = append(toc.Tracks, t)
+		}
+	}
+	if len(toc.Tracks) == 0 {
+		return nil, fmt.Errorf("Sheet has no tracks.")
+	}
+	if postgap != 0 {
+		toc.gaps = append(toc.gaps, tocGap{data, postgap})
+	}
+
+	for i, length := range lengths {
+		toc.Files = append(toc.Files, TOCFile{toc.lba(starts[i]), length.TotalFrames()})
+	}
+	toc.Leadout = toc.lba(data)
+
+	if last := toc.Tracks[len(toc.Tracks)-1]; toc.Leadout <= last.Offset {
+		return nil, fmt.Errorf("Lead-out is before the end of track %d.", last.Number)
+	}
+
+	return toc, nil
+}
+
+// LBA returns disc position of the given file position.
+func (toc *TOC) LBA(file int, time Time) (int, error) {
+	if file < 0 || file >= len(toc.Files) {
+		return 0, fmt.Errorf("There is no file %d.", file+1)
+	}
+	f := toc.Files[file]
+	if time.TotalFrames() > f.Length {
+		return 0, fmt.Errorf("Position %s is beyond the end of the file.", time)
+	}
+
+	return toc.lba(toc.data(f.Offset) + time.TotalFrames()), nil
+}
+
+// FilePosition returns index of the file and position in the file
+// of the given disc position. Error is returned for positions out of the
+// files, e.g. in PREGAP silence.
+func (toc *TOC) FilePosition(lba int) (int, Time, error) {
+	shift := 0
+	for _, gap := range toc.gaps {
+		start := gap.data + shift
+		if lba < start {
+			break
+		}
+		if lba < start+gap.length {
+			return 0, Time{}, fmt.Errorf("Position %d is in the generated silence.", lba)
+		}
+		shift += gap.length
+	}
+
+	pos := lba - shift
+	data := 0
+	for i, f := range toc.Files {
+		if pos >= data && pos < data+f.Length {
+			return i, TimeFromFrames(pos - data), nil
+		}
+		data += f.Length
+	}
+
+	return 0, Time{}, fmt.Errorf("Position %d is out of the disc.", lba)
+}
+
+// lba returns disc position of the concatenated files data position.
+func (toc *TOC) lba(data int) int {
+	lba := data
+	for _, gap := range toc.gaps {
+		if gap.data <= data {
+			lba += gap.length
+		}
+	}
+
+	return lba
+}
+
+// data returns concatenated files data position of the disc position
+// which is not in the generated silence.
+func (toc *TOC) data(lba int) int {
+	shift := 0
+	for _, gap := range toc.gaps {
+		if lba < gap.data+shift+gap.length {
+			break
+		}
+		shift += gap.length
+	}
+
+	return lba - shift
+}
+
+// Segment is a part of the sheet file.
+type Segment struct {
+	// Index of the file in CueSheet.Files.
+	File int
+	// Segment start in the file.
+	Start Time
+	// Segment length.
+	Length Time
+}
+
+// Segments returns parts of the files storing audio of the [start, end)
+// disc range. Generated PREGAP and POSTGAP silence is not stored
+// in the files and is skipped.
+func (toc *TOC) Segments(start int, end int) []Segment {
+	var segments []Segment
+
+	// Audio between the generated silence: [from, to) disc range
+	// stored at the shift before its disc position in the files.
+	from, shift := 0, 0
+	for i := 0; i <= len(toc.gaps); i++ {
+		to, length := toc.Leadout, 0
+		if i < len(toc.gaps) {
+			to, length = toc.gaps[i].data+shift, toc.gaps[i].length
+		}
+
+		if s, e := max(from, start), min(to, end); s < e {
+			segments = append(segments, toc.fileSegments(s-shift, e-shift)...)
+		}
+		from = to + length
+		shift += length
+	}
+
+	return segments
+}
+
+// audioLength returns number of frames stored in the files
+// for the [start, end) disc range.
+func (toc *TOC) audioLength(start int, end int) int {
+	n := 0
+	for _, s := range toc.Segments(start, end) {
+		n += s.Length.TotalFrames()
+	}
+
+	return n
+}
+
+// fileSegments splits [start, end) range of the concatenated
+// files data into the files parts.
+func (toc *TOC) fileSegments(start int, end int) []Segment {
+	var segments []Segment
+
+	data := 0
+	for i, f := range toc.Files {
+		if s, e := max(data, start), min(data+f.Length, end); s < e {
+			segments = append(segments, Segment{i, TimeFromFrames(s - data), TimeFromFrames(e - s)})
+		}
+		data += f.Length
+	}
+
+	return segments
+}
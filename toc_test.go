@@ -0,0 +1,163 @@
+package cue
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTOCFiles(t *testing.T) {
+	sheet, err := Parse(strings.NewReader(gapsAppended))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+
+	if _, err := sheet.TOC(Time{4, 2, 0}); err == nil {
+		t.Fatalf("TOC is built without all the file lengths")
+	}
+	toc, err := sheet.TOC(Time{4, 2, 0}, Time{5, 0, 0})
+	if err != nil {
+		t.Fatalf("Failed to build TOC. %s", err.Error())
+	}
+
+	etalon := []TOCTrack{
+		{Number: 1, Offset: 0, Indexes: []TOCIndex{{1, 0}}},
+		{Number: 2, Offset: 18150, Indexes: []TOCIndex{{0, 18000}, {1, 18150}}},
+		{Number: 3, Offset: 31650, Indexes: []TOCIndex{{0, 31575}, {1, 31650}}},
+	}
+	if !reflect.DeepEqual(toc.Tracks, etalon) {
+		t.Fatalf("Built %+v but %+v expected", toc.Tracks, etalon)
+	}
+	if toc.Leadout != 40650 {
+		t.Fatalf("Lead-out is %d", toc.Leadout)
+	}
+	if !reflect.DeepEqual(toc.Files, []TOCFile{{0, 18150}, {18150, 22500}}) {
+		t.Fatalf("Unexpected files %+v", toc.Files)
+	}
+
+	f, tm, err := toc.FilePosition(31575)
+	if err != nil || f != 1 || tm != (Time{2, 59, 0}) {
+		t.Fatalf("Position is %d %v. %v", f, tm, err)
+	}
+	if lba, err := toc.LBA(1, Time{2, 59, 0}); err != nil || lba != 31575 {
+		t.Fatalf("LBA is %d. %v", lba, err)
+	}
+	if _, _, err := toc.FilePosition(40650); err == nil {
+		t.Fatalf("Lead-out position is in the files")
+	}
+
+	segments := toc.Segments(18000, 18300)
+	etalonSegments := []Segment{{0, Time{4, 0, 0}, Time{0, 2, 0}}, {1, Time{0, 0, 0}, Time{0, 2, 0}}}
+	if !reflect.DeepEqual(segments, etalonSegments) {
+		t.Fatalf("Segments %+v but %+v expected", segments, etalonSegments)
+	}
+}
+
+func TestTOCPregapIndex0(t *testing.T) {
+	input := "FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    PREGAP 00:00:05\n" +
+		"    INDEX 00 00:00:10\n" +
+		"    INDEX 01 00:00:12\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	toc, err := sheet.TOC(Time{0, 0, 20})
+	if err != nil {
+		t.Fatalf("Failed to build TOC. %s", err.Error())
+	}
+
+	// Silence is inserted before INDEX 00 audio.
+	etalon := []TOCIndex{{0, 15}, {1, 17}}
+	if !reflect.DeepEqual(toc.Tracks[1].Indexes, etalon) || toc.Tracks[1].Offset != 17 {
+		t.Fatalf("Unexpected track %+v", toc.Tracks[1])
+	}
+	if toc.Leadout != 25 {
+		t.Fatalf("Lead-out is %d", toc.Leadout)
+	}
+	if _, _, err := toc.FilePosition(14); err == nil {
+		t.Fatalf("Position 14 is not in the silence")
+	}
+	if f, tm, err := toc.FilePosition(15); err != nil || f != 0 || tm != (Time{0, 0, 10}) {
+		t.Fatalf("Position of 15 is %v. %v", tm, err)
+	}
+	segments := toc.Segments(0, toc.Leadout)
+	etalonSegments := []Segment{{0, Time{0, 0, 0}, Time{0, 0, 10}}, {0, Time{0, 0, 10}, Time{0, 0, 10}}}
+	if !reflect.DeepEqual(segments, etalonSegments) {
+		t.Fatalf("Segments %+v but %+v expected", segments, etalonSegments)
+	}
+}
+
+func TestTOCGaps(t *testing.T) {
+	input := "FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    INDEX 01 00:00:00\n" +
+		"    POSTGAP 00:02:00\n" +
+		"  TRACK 02 AUDIO\n" +
+		"    PREGAP 00:01:00\n" +
+		"    INDEX 01 03:00:00\n" +
+		"    POSTGAP 00:00:10\n"
+
+	sheet, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Failed to parse. %s", err.Error())
+	}
+	toc, err := sheet.TOC(Time{5, 0, 0})
+	if err != nil {
+		t.Fatalf("Failed to build TOC. %s", err.Error())
+	}
+
+	if toc.Tracks[1].Offset != 13725 || toc.Tracks[1].Pregap != 75 || toc.Tracks[0].Postgap != 150 {
+		t.Fatalf("Unexpected track %+v", toc.Tracks[1])
+	}
+	if toc.Leadout != 22735 {
+		t.Fatalf("Lead-out is %d", toc.Leadout)
+	}
+
+	tests := []struct {
+		lba  int
+		time Time
+		ok   bool
+	}{
+		{100, Time{0, 1, 25}, true},
+		{13499, Time{2, 59, 74}, true},
+		{13500, Time{}, false},
+		{13724, Time{}, false},
+		{13725, Time{3, 0, 0}, true},
+		{22724, Time{4, 59, 74}, true},
+		{22725, Time{}, false},
+	}
+	for _, test := range tests {
+		f, tm, err := toc.FilePosition(test.lba)
+		if (err == nil) != test.ok || tm != test.time || f != 0 {
+			t.Fatalf("Position of %d is %v. %v", test.lba, tm, err)
+		}
+		if !test.ok {
+			continue
+		}
+		if lba, err := toc.LBA(0, tm); err != nil || lba != test.lba {
+			t.Fatalf("LBA of %v is %d but %d expected. %v", tm, lba, test.lba, err)
+		}
+	}
+
+	segmentTests := []struct {
+		start    int
+		end      int
+		segments []Segment
+	}{
+		{0, toc.Leadout, []Segment{{0, Time{0, 0, 0}, Time{3, 0, 0}}, {0, Time{3, 0, 0}, Time{2, 0, 0}}}},
+		{13400, 13800, []Segment{{0, Time{2, 58, 50}, Time{0, 1, 25}}, {0, Time{3, 0, 0}, Time{0, 1, 0}}}},
+		{13500, 13725, nil},
+	}
+	for _, test := range segmentTests {
+		segments := toc.Segments(test.start, test.end)
+		if !reflect.DeepEqual(segments, test.segments) {
+			t.Fatalf("Segments of %d-%d are %+v but %+v expected",
+				test.start, test.end, segments, test.segments)
+		}
+	}
+}
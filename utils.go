@@ -1,11 +1,23 @@
 // Some helper functions collection.
 package cue
 
+import (
+	"unicode/utf8"
+)
+
 // stringTruncate truncates string up to newLen characters.
 // If given string is shorter than newLen if will be returned without any changes.
 func stringTruncate(str string, newLen int) string {
-	if len(str) > newLen {
-		return str[:newLen]
+	if utf8.RuneCountInString(str) <= newLen {
+		return str
+	}
+
+	n := 0
+	for i := range str {
+		if n == newLen {
+			return str[:i]
+		}
+		n++
 	}
 
 	return str
@@ -2,6 +2,7 @@ package cue
 
 import (
 	"testing"
+	"unicode/utf8"
 )
 
 func TestUtils(t *testing.T) {
@@ -22,3 +23,15 @@ func TestUtils(t *testing.T) {
 		}
 	}
 }
+
+func TestUtilsRunes(t *testing.T) {
+	const input string = "Группа крови"
+
+	for i := 0; i < len(input); i++ {
+		out := stringTruncate(input, i)
+		expected := min(i, utf8.RuneCountInString(input))
+		if !utf8.ValidString(out) || utf8.RuneCountInString(out) != expected {
+			t.Fatalf("Assertion failed: stringTruncate(\"%s\", %d) == %q", input, i, out)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package cue
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// scope identifies the sheet entity a command belongs to.
+type scope struct {
+	// Index of the file in CueSheet.Files or -1 for disc commands.
+	file int
+	// Index of the track in File.Tracks or -1 for disc and file commands.
+	track int
+}
+
+// discScope is the scope of commands describing the whole disc.
+var discScope = scope{-1, -1}
+
+// command is one cue sheet command prepared for writing.
+type command struct {
+	// Entity the command describes.
+	scope scope
+	// Indentation level (0 -- disc and files, 1 -- tracks, 2 -- track's commands).
+	level int
+	// Command name.
+	name string
+	// Command parameters.
+	params []string
+	// quoted[i] is true if params[i] is a text value which should be quoted.
+	quoted []bool
+}
+
+// Write writes sheet to the w in the cue sheet format.
+// Commands are written in the canonical order, so the output of the Write
+// can be parsed back with the Parse function. Error is returned for sheets
+// with unknown types and flags or times out of range.
+func Write(w io.Writer, sheet *CueSheet) error {
+	cmds, err := sheetCommands(sheet)
+	if err != nil {
+		return err
+	}
+	for _, cmd := range cmds {
+		if _, err := io.WriteString(w, formatCommand(cmd)+"\n"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler interface.
+func (sheet *CueSheet) MarshalText() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := Write(buf, sheet); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// sheetCommands returns list of commands describing the sheet.
+// Error is returned if the sheet can't be written.
+func sheetCommands(sheet *CueSheet) ([]command, error) {
+	var cmds []command
+
+	cmds = append(cmds, remCommands(0, &sheet.Rem)...)
+	for _, comment := range sheet.Comments {
+		cmds = append(cmds, remCommand(0, comment))
+	}
+	if sheet.Catalog != "" {
+		cmds = append(cmds, newCommand(0, "CATALOG", sheet.Catalog))
+	}
+	if sheet.CdTextFile != "" {
+		cmds = append(cmds, newTextCommand(0, "CDTEXTFILE", sheet.CdTextFile))
+	}
+	if sheet.Performer != "" {
+		cmds = append(cmds, newTextCommand(0, "PERFORMER", sheet.Performer))
+	}
+	if sheet.Title != "" {
+		cmds = append(cmds, newTextCommand(0, "TITLE", sheet.Title))
+	}
+	if sheet.Songwriter != "" {
+		cmds = append(cmds, newTextCommand(0, "SONGWRITER", sheet.Songwriter))
+	}
+	setScope(cmds, discScope)
+
+	// Write FILE commands up to the n-th file.
+	files := 0
+	writeFiles := func(n int) error {
+		for ; files <= n && files < len(sheet.Files); files++ {
+			file := &sheet.Files[files]
+			// Unnamed file keeps tracks appeared without FILE command.
+			if file.Name == "" {
+				continue
+			}
+			fileType, ok := file.Type.name()
+			if !ok {
+				return fmt.Errorf("File %s has unknown type %d.", file.Name, int(file.Type))
+			}
+			cmds = append(cmds, command{
+				scope:  scope{files, -1},
+				level:  0,
+				name:   "FILE",
+				params: []string{file.Name, fileType},
+				quoted: []bool{true, false},
+			})
+		}
+
+		return nil
+	}
+
+	for i := range sheet.Files {
+		file := &sheet.Files[i]
+		if err := writeFiles(i); err != nil {
+			return nil, err
+		}
+
+		for j := range file.Tracks {
+			track := &file.Tracks[j]
+			trackCmds, err := trackCommands(track)
+			if err != nil {
+				return nil, err
+			}
+			setScope(trackCmds, scope{i, j})
+
+			// Indexes continued in the next files follow their FILE commands.
+			k := 0
+			for _, cmd := range trackCmds {
+				if cmd.name == "INDEX" {
+					if err := writeFiles(i + track.Indexes[k].FileOffset); err != nil {
+						return nil, err
+					}
+					k++
+				}
+				cmds = append(cmds, cmd)
+			}
+		}
+	}
+
+	return cmds, nil
+}
+
+// trackCommands returns list of commands describing the track.
+// Error is returned if the track can't be written.
+func trackCommands(track *Track) ([]command, error) {
+	dataType, ok := track.DataType.name()
+	if !ok {
+		return nil, fmt.Errorf("Track %d has unknown datatype %d.", track.Number, int(track.DataType))
+	}
+	cmds := []command{
+		newCommand(1, "TRACK", fmt.Sprintf("%02d", track.Number), dataType),
+	}
+
+	if len(track.Flags) != 0 {
+		flags := make([]string, len(track.Flags))
+		for i, flag := range track.Flags {
+			name, ok := flag.name()
+			if !ok {
+				return nil, fmt.Errorf("Track %d has unknown flag %d.", track.Number, int(flag))
+			}
+			flags[i] = name
+		}
+		cmds = append(cmds, newCommand(2, "FLAGS", flags...))
+	}
+	if track.Isrc != "" {
+		cmds = append(cmds, newCommand(2, "ISRC", track.Isrc))
+	}
+	if track.Title != "" {
+		cmds = append(cmds, newTextCommand(2, "TITLE", track.Title))
+	}
+	if track.Performer != "" {
+		cmds = append(cmds, newTextCommand(2, "PERFORMER", track.Performer))
+	}
+	if track.Songwriter != "" {
+		cmds = append(cmds, newTextCommand(2, "SONGWRITER", track.Songwriter))
+	}
+	cmds = append(cmds, remCommands(2, &track.Rem)...)
+	for _, comment := range track.Comments {
+		cmds = append(cmds, remCommand(2, comment))
+	}
+	if track.Pregap != (Time{}) {
+		if !validTime(track.Pregap) {
+			return nil, fmt.Errorf("Track %d PREGAP %s is out of range.",
+				track.Number, formatTime(track.Pregap))
+		}
+		cmds = append(cmds, newCommand(2, "PREGAP", formatTime(track.Pregap)))
+	}
+	for _, index := range track.Indexes {
+		if !validTime(index.Time) {
+			return nil, fmt.Errorf("Track %d INDEX %02d time %s is out of range.",
+				track.Number, index.Number, formatTime(index.Time))
+		}
+		cmds = append(cmds, newCommand(2, "INDEX",
+			fmt.Sprintf("%02d", index.Number), formatTime(index.Time)))
+	}
+	if track.Postgap != (Time{}) {
+		if !validTime(track.Postgap) {
+			return nil, fmt.Errorf("Track %d POSTGAP %s is out of range.",
+				track.Number, formatTime(track.Postgap))
+		}
+		cmds = append(cmds, newCommand(2, "POSTGAP", formatTime(track.Postgap)))
+	}
+
+	return cmds, nil
+}
+
+// validTime returns true if the time can be written in mm:ss:ff format:
+// it is not negative, seconds and frames are in their ranges.
+func validTime(t Time) bool {
+	return t.Min >= 0 && t.Sec >= 0 && t.Sec < 60 && t.Frames >= 0 && t.Frames < FramesPerSecond
+}
+
+// setScope sets scope for all the given commands.
+func setScope(cmds []command, s scope) {
+	for i := range cmds {
+		cmds[i].scope = s
+	}
+}
+
+// newCommand returns command with non-text parameters.
+func newCommand(level int, name string, params ...string) command {
+	return command{
+		level:  level,
+		name:   name,
+		params: params,
+		quoted: make([]bool, len(params)),
+	}
+}
+
+// newTextCommand returns command with the only one text parameter.
+func newTextCommand(level int, name string, text string) command {
+	return command{
+		level:  level,
+		name:   name,
+		params: []string{text},
+		quoted: []bool{true},
+	}
+}
+
+// remCommand returns REM command for the given free-form comment.
+// Comment is written word by word, so it will be parsed back to the same string.
+func remCommand(level int, comment string) command {
+	words := strings.Split(comment, " ")
+	if isRemMetadata(words) {
+		// Comment can't be written as REM KEY value pair.
+		return newTextCommand(level, "REM", comment)
+	}
+	for _, word := range words {
+		if word == "" {
+			// Multiple spaces can't be saved splitted into words.
+			return newTextCommand(level, "REM", comment)
+		}
+	}
+
+	return newCommand(level, "REM", words...)
+}
+
+// formatCommand returns string representation of the command
+// (without trailing new line character).
+func formatCommand(cmd command) string {
+	buf := bytes.NewBufferString(strings.Repeat("  ", cmd.level))
+	buf.WriteString(cmd.name)
+
+	for i, param := range cmd.params {
+		buf.WriteByte(' ')
+		if cmd.quoted[i] {
+			buf.WriteString(quoteString(param))
+		} else {
+			buf.WriteString(quoteStringIfNeeded(param))
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,128 @@
+package cue
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	file, err := os.Open("test.cue")
+	if err != nil {
+		t.Fatalf("Failed to open file. %s", err.Error())
+	}
+	defer file.Close()
+
+	sheet, err := Parse(file)
+	if err != nil {
+		t.Fatalf("Failed to parse file. %s", err.Error())
+	}
+
+	assertRoundTrip(t, sheet)
+}
+
+func TestWriteQuoting(t *testing.T) {
+	sheet := &CueSheet{
+		Catalog:   "1234567890123",
+		Title:     "Say \"Hello\"\tto C:\\Music",
+		Performer: "It's me",
+		Comments:  []string{"GENRE Hard Rock", "COMMENT two  spaces", "it's"},
+		Files: []File{
+			{Name: "disc 1.bin", Type: FileTypeBinary, Tracks: []Track{
+				{Number: 1, DataType: DataTypeMode1_2352, Flags: []TrackFlag{TrackFlagDcp, TrackFlagPre},
+					Isrc: "ABCDE1234567", Pregap: Time{0, 2, 0},
+					Indexes: []Index{{Number: 1, Time: Time{0, 0, 0}}}},
+				{Number: 2, DataType: DataTypeAudio, Title: "Second",
+					Indexes: []Index{{Number: 0, Time: Time{3, 1, 74}}, {Number: 1, Time: Time{3, 3, 0}}},
+					Postgap: Time{0, 1, 0}},
+			}},
+			{Name: "disc 2.wav", Type: FileTypeWave, Tracks: []Track{
+				{Number: 3, DataType: DataTypeAudio, Songwriter: "Somebody",
+					Indexes: []Index{{Number: 1, Time: Time{0, 0, 0}}}},
+			}},
+		},
+	}
+
+	assertRoundTrip(t, sheet)
+}
+
+func TestWriteFormat(t *testing.T) {
+	sheet := &CueSheet{
+		Title: "Album",
+		Files: []File{
+			{Name: "a.wav", Type: FileTypeWave, Tracks: []Track{
+				{Number: 1, DataType: DataTypeAudio, Title: "Track",
+					Indexes: []Index{{Number: 1, Time: Time{0, 0, 0}}}},
+			}},
+		},
+	}
+	etalon := "TITLE \"Album\"\n" +
+		"FILE \"a.wav\" WAVE\n" +
+		"  TRACK 01 AUDIO\n" +
+		"    TITLE \"Track\"\n" +
+		"    INDEX 01 00:00:00\n"
+
+	text, err := sheet.MarshalText()
+	if err != nil {
+		t.Fatalf("Failed to write sheet. %s", err.Error())
+	}
+	if string(text) != etalon {
+		t.Fatalf("Written:\n%s\nbut expected:\n%s", text, etalon)
+	}
+}
+
+func TestWriteInvalid(t *testing.T) {
+	track := func() Track {
+		return Track{Number: 1, DataType: DataTypeAudio,
+			Indexes: []Index{{Number: 1, Time: Time{0, 0, 0}}}}
+	}
+	sheet := func(file File) *CueSheet {
+		return &CueSheet{Files: []File{file}}
+	}
+	badType := track()
+	badType.DataType = TrackDataType(100)
+	badFlag := track()
+	badFlag.Flags = []TrackFlag{TrackFlagDcp, TrackFlag(100)}
+	badIndex := track()
+	badIndex.Indexes[0].Time = Time{0, 75, 0}
+	badPregap := track()
+	badPregap.Pregap = Time{0, 0, 75}
+	badPostgap := track()
+	badPostgap.Postgap = Time{-1, 0, 0}
+
+	sheets := []*CueSheet{
+		sheet(File{Name: "a.wav", Type: FileType(100), Tracks: []Track{track()}}),
+		sheet(File{Name: "a.wav", Type: FileTypeWave, Tracks: []Track{badType}}),
+		sheet(File{Name: "a.wav", Type: FileTypeWave, Tracks: []Track{badFlag}}),
+		sheet(File{Name: "a.wav", Type: FileTypeWave, Tracks: []Track{badIndex}}),
+		sheet(File{Name: "a.wav", Type: FileTypeWave, Tracks: []Track{badPregap}}),
+		sheet(File{Name: "a.wav", Type: FileTypeWave, Tracks: []Track{badPostgap}}),
+	}
+
+	for i, s := range sheets {
+		buf := new(bytes.Buffer)
+		if err := Write(buf, s); err == nil {
+			t.Fatalf("Invalid sheet %d is written:\n%s", i, buf.String())
+		}
+		if _, err := s.MarshalText(); err == nil {
+			t.Fatalf("Invalid sheet %d is marshaled", i)
+		}
+	}
+}
+
+func assertRoundTrip(t *testing.T, sheet *CueSheet) {
+	buf := new(bytes.Buffer)
+	if err := Write(buf, sheet); err != nil {
+		t.Fatalf("Failed to write sheet. %s", err.Error())
+	}
+
+	parsed, err := Parse(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Failed to parse written sheet. %s\n%s", err.Error(), buf.String())
+	}
+
+	if !reflect.DeepEqual(sheet, parsed) {
+		t.Fatalf("Parsed sheet %+v differs from the written one %+v", parsed, sheet)
+	}
+}